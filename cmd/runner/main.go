@@ -5,20 +5,27 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/v1Flows/runner/config"
 	"github.com/v1Flows/runner/internal/endpoints"
 	internal_executions "github.com/v1Flows/runner/internal/executions"
+	"github.com/v1Flows/runner/internal/journal"
+	"github.com/v1Flows/runner/internal/outbox"
 	"github.com/v1Flows/runner/internal/runner"
 	"github.com/v1Flows/runner/internal/worker"
+	"github.com/v1Flows/runner/pkg/platform"
 	"github.com/v1Flows/runner/pkg/plugins"
 	shared_models "github.com/v1Flows/shared-library/pkg/models"
 
 	"github.com/alecthomas/kingpin/v2"
 )
 
+// shutdownCancelTimeout is how long canceled executions get to report their end
+const shutdownCancelTimeout = 10 * time.Second
+
 var (
 	log        = logrus.New()
 	version    = "1.0.3"
@@ -59,32 +66,74 @@ func main() {
 
 	logging(cfg.LogLevel)
 
+	if err := journal.Init(cfg.WorkspaceDir); err != nil {
+		log.Fatalf("Failed to initialize journal: %v", err)
+	}
+
+	if cfg.Outbox.Enabled {
+		if err := outbox.Init(cfg.WorkspaceDir); err != nil {
+			log.Fatalf("Failed to initialize outbox: %v", err)
+		}
+	}
+
 	loadedPlugins, modelPlugins, actionPlugins, endpointPlugins := plugins.Init(cfg)
 
+	modelPlugins, actionPlugins = internal_executions.RegisterBuiltinPlugins(cfg, loadedPlugins, modelPlugins, actionPlugins)
+
 	actions := internal_executions.RegisterActions(actionPlugins)
 
 	// RunnerID might have changed after registration, so fetch the config again
 	cfg = configManager.GetConfig()
 
+	pool := internal_executions.NewPool(cfg, actions, loadedPlugins)
+
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 
-	if cfg.Alertflow.Enabled {
-		endpoints := endpoints.RegisterEndpoints(endpointPlugins)
-		log.Info("Registering at AlertFlow")
-		runner.RegisterAtAPI("alertflow", version, modelPlugins, actions, endpoints)
-		go runner.SendHeartbeat("alertflow")
-		Init("alertflow", cfg, router, actions, endpointPlugins, loadedPlugins)
+	connections, err := platform.Connections(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the alert endpoints of a connection are prefixed with its name if several connections receive alerts
+	var alertPlatforms []string
+	for _, p := range connections {
+		if p.Alerts() {
+			alertPlatforms = append(alertPlatforms, p.Name())
+		}
+	}
+
+	for _, p := range connections {
+		// alert endpoints are only registered at platforms with alerts
+		var alertEndpoints []shared_models.Endpoint
+		if p.Alerts() {
+			alertEndpoints = endpoints.RegisterEndpoints(endpointPlugins, endpoints.Prefix(alertPlatforms, p.Name()))
+		}
+		log.Infof("Registering at %s", p.Name())
+		runner.RegisterAtAPI(p.Name(), version, modelPlugins, actions, alertEndpoints)
+		go runner.SendHeartbeat(p.Name())
+	}
+
+	// runner IDs might have changed after registration, so fetch the config again
+	cfg = configManager.GetConfig()
+
+	// updates queued before the restart are sent before executions are recovered or started
+	if cfg.Outbox.Enabled {
+		outbox.Replay(cfg)
+		go outbox.Start(cfg)
 	}
 
-	if cfg.ExFlow.Enabled {
-		log.Info("Registering at ExFlow")
-		runner.RegisterAtAPI("exflow", version, modelPlugins, actions, nil)
-		go runner.SendHeartbeat("exflow")
-		Init("exflow", cfg, router, actions, endpointPlugins, loadedPlugins)
+	Init(cfg, router, pool, connections, alertPlatforms, endpointPlugins, loadedPlugins)
+
+	// executions interrupted by the last shutdown are only recovered by runners which execute them
+	if strings.ToLower(cfg.Mode) != "listener" {
+		internal_executions.RecoverExecutions(configManager.GetConfig(), pool)
 	}
 
-	go endpoints.ReadyEndpoint(cfg, router)
+	// the router is served in every mode, e.g. for the status of the pool
+	endpoints.ReadyEndpoint(cfg, router)
+	endpoints.StatusEndpoint(router, pool)
+	go endpoints.Serve(cfg, router)
 
 	// Handle graceful shutdown
 	sigs := make(chan os.Signal, 1)
@@ -92,29 +141,58 @@ func main() {
 	<-sigs
 
 	log.Info("Shutting down...")
+
+	// stop claiming new executions and alerts
+	pool.Close()
+	endpoints.StopAcceptingAlerts()
+
+	log.Infof("Waiting up to %s for running executions", cfg.ShutdownGracePeriod)
+	if !pool.Wait(cfg.ShutdownGracePeriod) {
+		log.Warn("Canceling executions which are still running")
+		pool.CancelRunning()
+		if !pool.Wait(shutdownCancelTimeout) {
+			log.Warn("Executions did not end in time, they are recovered on the next start")
+		}
+	}
+
+	if cfg.Outbox.Enabled {
+		outbox.Replay(cfg)
+		if n := outbox.Len(); n > 0 {
+			log.Warnf("%d updates could not be sent, they are sent on the next start", n)
+		}
+	}
+
+	for _, p := range connections {
+		runner.ResetBusy(p.Name(), cfg)
+	}
+
 	plugins.ShutdownPlugins()
 	log.Info("Shutdown complete")
 }
 
-func Init(platform string, cfg config.Config, router *gin.Engine, actions []shared_models.Action, endpointPlugins []shared_models.Plugin, loadedPlugins map[string]plugins.Plugin) {
+func Init(cfg config.Config, router *gin.Engine, pool *internal_executions.Pool, connections []platform.Platform, alertPlatforms []string, endpointPlugins []shared_models.Plugin, loadedPlugins map[string]plugins.Plugin) {
 	switch strings.ToLower(cfg.Mode) {
 	case "master":
 		log.Info("Runner is in Master Mode")
 		log.Info("Starting Execution Checker")
-		go worker.StartWorker(platform, cfg, actions, loadedPlugins)
-		if platform == "alertflow" {
-			log.Info("Starting Alert Listener")
-			go endpoints.InitEndpointRouter(cfg, router, "alertflow", endpointPlugins, loadedPlugins)
+		for _, p := range connections {
+			go worker.StartWorker(p.Name(), cfg, pool)
+		}
+		if len(alertPlatforms) > 0 {
+			log.Info("Registering Alert Endpoints")
+			endpoints.InitEndpointRouter(cfg, router, alertPlatforms, endpointPlugins, loadedPlugins)
 		}
 	case "worker":
 		log.Info("Runner is in Worker Mode")
 		log.Info("Starting Execution Checker")
-		go worker.StartWorker(platform, cfg, actions, loadedPlugins)
+		for _, p := range connections {
+			go worker.StartWorker(p.Name(), cfg, pool)
+		}
 	case "listener":
 		log.Info("Runner is in Listener Mode")
-		if platform == "alertflow" {
-			log.Info("Starting Alert Listener")
-			go endpoints.InitEndpointRouter(cfg, router, "alertflow", endpointPlugins, loadedPlugins)
+		if len(alertPlatforms) > 0 {
+			log.Info("Registering Alert Endpoints")
+			endpoints.InitEndpointRouter(cfg, router, alertPlatforms, endpointPlugins, loadedPlugins)
 		}
 	}
 }
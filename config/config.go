@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -27,26 +28,143 @@ type Config struct {
 	WorkspaceDir string          `mapstructure:"workspace_dir" validate:"dir"`
 	PluginDir    string          `mapstructure:"plugin_dir" validate:"dir"`
 	Plugins      []PluginConfig  `mapstructure:"plugins"`
+
+	MaxConcurrentExecutions int               `mapstructure:"max_concurrent_executions" validate:"min=1"`
+	Aggregation             AggregationConfig `mapstructure:"aggregation"`
+	StepTimeout             time.Duration     `mapstructure:"step_timeout"`
+	ExecutionTimeout        time.Duration     `mapstructure:"execution_timeout"`
+	StatusCheckInterval     time.Duration     `mapstructure:"status_check_interval"`
+	Journal                 JournalConfig     `mapstructure:"journal"`
+	ShutdownGracePeriod     time.Duration     `mapstructure:"shutdown_grace_period"`
+
+	ConcurrencyGroups []ConcurrencyGroupConfig `mapstructure:"concurrency_groups"`
+	Priority          PriorityConfig           `mapstructure:"priority"`
+	DryRun            DryRunConfig             `mapstructure:"dry_run"`
+
+	API    APIConfig    `mapstructure:"api"`
+	Outbox OutboxConfig `mapstructure:"outbox"`
+	Push   PushConfig   `mapstructure:"push"`
+
+	// Platforms are the connections of the runner. Without any, the alertflow
+	// and exflow blocks are used as connections with their own names.
+	Platforms []PlatformConfig `mapstructure:"platforms"`
+}
+
+// PlatformConfig is a connection to an instance of a v1Flows product
+type PlatformConfig struct {
+	// Name identifies the connection, executions are routed by it
+	Name string `mapstructure:"name" validate:"required"`
+	// Type selects the platform driver, e.g. alertflow or exflow
+	Type     string               `mapstructure:"type" validate:"required"`
+	URL      string               `mapstructure:"url" validate:"required,url"`
+	APIKey   string               `mapstructure:"api_key" validate:"required"`
+	RunnerID string               `mapstructure:"runner_id"`
+	Pipeline []PipelineStepConfig `mapstructure:"pipeline"`
 }
 
 type AlertflowConfig struct {
-	Enabled  bool   `mapstructure:"enabled"`
-	URL      string `mapstructure:"url" validate:"required,url"`
-	RunnerID string `mapstructure:"runner_id"`
-	APIKey   string `mapstructure:"api_key" validate:"required"`
+	Enabled  bool                 `mapstructure:"enabled"`
+	URL      string               `mapstructure:"url" validate:"required,url"`
+	RunnerID string               `mapstructure:"runner_id"`
+	APIKey   string               `mapstructure:"api_key" validate:"required"`
+	Pipeline []PipelineStepConfig `mapstructure:"pipeline"`
 }
 
 type exflowConfig struct {
-	Enabled  bool   `mapstructure:"enabled"`
-	URL      string `mapstructure:"url" validate:"required,url"`
-	RunnerID string `mapstructure:"runner_id"`
-	APIKey   string `mapstructure:"api_key" validate:"required"`
+	Enabled  bool                 `mapstructure:"enabled"`
+	URL      string               `mapstructure:"url" validate:"required,url"`
+	RunnerID string               `mapstructure:"runner_id"`
+	APIKey   string               `mapstructure:"api_key" validate:"required"`
+	Pipeline []PipelineStepConfig `mapstructure:"pipeline"`
+}
+
+// PipelineStepConfig is a plugin step which runs before the actions of every flow
+type PipelineStepConfig struct {
+	Plugin string                `mapstructure:"plugin" validate:"required"`
+	Params []PipelineParamConfig `mapstructure:"params"`
+}
+
+// PipelineParamConfig is a param of a pipeline step. The value is a template
+// with the fields AlertID, FlowID, ExecutionID, RunnerID and Platform.
+type PipelineParamConfig struct {
+	Key   string `mapstructure:"key" validate:"required"`
+	Value string `mapstructure:"value"`
 }
 
 type EndpointConfig struct {
 	Port int `mapstructure:"port" validate:"required,min=1024,max=65535"`
 }
 
+// AggregationConfig decides how the results of the flow action steps make up the execution status
+type AggregationConfig struct {
+	Policy string `mapstructure:"policy" validate:"oneof=fail_fast all_must_succeed quorum best_effort"`
+	Quorum int    `mapstructure:"quorum" validate:"min=1,max=100"`
+}
+
+// JournalConfig decides what happens to executions which were interrupted by a restart of the runner
+type JournalConfig struct {
+	RecoveryPolicy string `mapstructure:"recovery_policy" validate:"oneof=resume fail cancel"`
+}
+
+// ConcurrencyGroupConfig keeps executions of matching flows with the same key from running at the same time
+type ConcurrencyGroupConfig struct {
+	// Flow is the ID or name of the flow, an empty flow matches all flows
+	Flow   string `mapstructure:"flow"`
+	Key    string `mapstructure:"key" validate:"required"`
+	Policy string `mapstructure:"policy" validate:"omitempty,oneof=queue skip cancel_older"`
+}
+
+// PriorityConfig decides the order in which queued executions are started
+type PriorityConfig struct {
+	// SeverityField is a template which renders the severity of the alert
+	SeverityField string               `mapstructure:"severity_field"`
+	Severities    map[string]int       `mapstructure:"severities"`
+	Rules         []PriorityRuleConfig `mapstructure:"rules"`
+}
+
+// PriorityRuleConfig adds its priority to every execution it matches. A rule
+// matches the flow by ID or name and the when condition, empty fields match all executions.
+type PriorityRuleConfig struct {
+	Flow     string `mapstructure:"flow"`
+	When     string `mapstructure:"when"`
+	Priority int    `mapstructure:"priority"`
+}
+
+// APIConfig tunes the calls to the platform APIs
+type APIConfig struct {
+	// Timeout applies to every attempt of a call
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Retries is how often idempotent calls are retried
+	Retries      int           `mapstructure:"retries"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+}
+
+// OutboxConfig buffers the updates of executions and steps while a platform is unreachable
+type OutboxConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// ReplayInterval is how often queued updates are sent again
+	ReplayInterval time.Duration `mapstructure:"replay_interval"`
+}
+
+// PushConfig lets the platforms push executions and changes of running executions over an event stream
+type PushConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PollInterval is how often pending executions are still polled while the stream is connected
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// ReconnectBackoff is the first delay before reconnecting a lost stream, it doubles up to a minute
+	ReconnectBackoff time.Duration `mapstructure:"reconnect_backoff"`
+	// IdleTimeout reconnects a stream which received neither an event nor a keep-alive for that long
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+}
+
+// DryRunConfig selects executions which are processed without side effects
+type DryRunConfig struct {
+	// Enabled runs every execution of the runner as dry run
+	Enabled bool `mapstructure:"enabled"`
+	// Flows are the IDs or names of flows whose executions are dry runs
+	Flows []string `mapstructure:"flows"`
+}
+
 type PluginConfig struct {
 	Name       string            `mapstructure:"name" validate:"required"`
 	Repository string            `mapstructure:"repository" validate:"required,url"`
@@ -58,8 +176,26 @@ const (
 	defaultLogLevel = "info"
 	defaultMode     = "master"
 	defaultPort     = 8081
+
+	defaultMaxConcurrentExecutions = 5
+	defaultAggregationPolicy       = "all_must_succeed"
+	defaultAggregationQuorum       = 50
+	defaultStatusCheckInterval     = 10 * time.Second
+	defaultJournalRecoveryPolicy   = "fail"
+	defaultShutdownGracePeriod     = 30 * time.Second
+	defaultPrioritySeverityField   = "{{ .Alert.Payload.commonLabels.severity }}"
+	defaultAPITimeout              = 10 * time.Second
+	defaultAPIRetries              = 3
+	defaultAPIRetryBackoff         = time.Second
+	defaultOutboxReplayInterval    = 10 * time.Second
+	defaultPushPollInterval        = time.Minute
+	defaultPushReconnectBackoff    = 5 * time.Second
+	defaultPushIdleTimeout         = time.Minute
 )
 
+// PipelineCollectData loads the flow and the alert, every pipeline has to run it
+const PipelineCollectData = "collect_data"
+
 var (
 	instance *ConfigurationManager
 	once     sync.Once
@@ -141,6 +277,50 @@ func (cm *ConfigurationManager) setDefaults(config *Config) {
 	if config.Endpoints.Port == 0 {
 		config.Endpoints.Port = defaultPort
 	}
+	if config.MaxConcurrentExecutions == 0 {
+		config.MaxConcurrentExecutions = defaultMaxConcurrentExecutions
+	}
+	if config.Aggregation.Policy == "" {
+		config.Aggregation.Policy = defaultAggregationPolicy
+	}
+	if config.Aggregation.Quorum == 0 {
+		config.Aggregation.Quorum = defaultAggregationQuorum
+	}
+	if config.StatusCheckInterval == 0 {
+		config.StatusCheckInterval = defaultStatusCheckInterval
+	}
+	if config.ShutdownGracePeriod == 0 {
+		config.ShutdownGracePeriod = defaultShutdownGracePeriod
+	}
+	if config.Priority.SeverityField == "" {
+		config.Priority.SeverityField = defaultPrioritySeverityField
+	}
+	if config.API.Timeout == 0 {
+		config.API.Timeout = defaultAPITimeout
+	}
+	if config.API.Retries == 0 {
+		config.API.Retries = defaultAPIRetries
+	}
+	if config.API.RetryBackoff == 0 {
+		config.API.RetryBackoff = defaultAPIRetryBackoff
+	}
+	if config.Journal.RecoveryPolicy == "" {
+		config.Journal.RecoveryPolicy = defaultJournalRecoveryPolicy
+	}
+	// the outbox is enabled unless it is turned off in the config file
+	config.Outbox.Enabled = true
+	if config.Outbox.ReplayInterval == 0 {
+		config.Outbox.ReplayInterval = defaultOutboxReplayInterval
+	}
+	if config.Push.PollInterval == 0 {
+		config.Push.PollInterval = defaultPushPollInterval
+	}
+	if config.Push.ReconnectBackoff == 0 {
+		config.Push.ReconnectBackoff = defaultPushReconnectBackoff
+	}
+	if config.Push.IdleTimeout == 0 {
+		config.Push.IdleTimeout = defaultPushIdleTimeout
+	}
 	if config.WorkspaceDir == "" {
 		// get the current working directory and add plugins folder
 		currentDir, err := os.Getwd()
@@ -162,23 +342,144 @@ func (cm *ConfigurationManager) setDefaults(config *Config) {
 }
 
 func (cm *ConfigurationManager) validateConfig(config *Config) error {
-	if config.Alertflow.Enabled {
-		if config.Alertflow.APIKey == "" {
-			return fmt.Errorf("api_key is required")
+	// the alertflow and exflow blocks are only used without configured platforms
+	if len(config.Platforms) == 0 {
+		if config.Alertflow.Enabled {
+			if config.Alertflow.APIKey == "" {
+				return fmt.Errorf("api_key is required")
+			}
+			if config.Alertflow.URL == "" {
+				return fmt.Errorf("alertflow URL is required")
+			}
+			config.Platforms = append(config.Platforms, PlatformConfig{
+				Name:     "alertflow",
+				Type:     "alertflow",
+				URL:      config.Alertflow.URL,
+				APIKey:   config.Alertflow.APIKey,
+				RunnerID: config.Alertflow.RunnerID,
+				Pipeline: config.Alertflow.Pipeline,
+			})
 		}
-		if config.Alertflow.URL == "" {
-			return fmt.Errorf("alertflow URL is required")
+		if config.ExFlow.Enabled {
+			if config.ExFlow.APIKey == "" {
+				return fmt.Errorf("api_key is required")
+			}
+			if config.ExFlow.URL == "" {
+				return fmt.Errorf("exflow URL is required")
+			}
+			config.Platforms = append(config.Platforms, PlatformConfig{
+				Name:     "exflow",
+				Type:     "exflow",
+				URL:      config.ExFlow.URL,
+				APIKey:   config.ExFlow.APIKey,
+				RunnerID: config.ExFlow.RunnerID,
+				Pipeline: config.ExFlow.Pipeline,
+			})
 		}
 	}
-	if config.ExFlow.Enabled {
-		if config.ExFlow.APIKey == "" {
-			return fmt.Errorf("api_key is required")
+	if err := validatePlatforms(config.Platforms); err != nil {
+		return err
+	}
+	if config.MaxConcurrentExecutions < 1 {
+		return fmt.Errorf("max_concurrent_executions must be at least 1")
+	}
+	switch config.Aggregation.Policy {
+	case "fail_fast", "all_must_succeed", "quorum", "best_effort":
+	default:
+		return fmt.Errorf("unknown aggregation policy: %s", config.Aggregation.Policy)
+	}
+	if config.Aggregation.Quorum < 1 || config.Aggregation.Quorum > 100 {
+		return fmt.Errorf("aggregation quorum must be between 1 and 100")
+	}
+	if config.StepTimeout < 0 || config.ExecutionTimeout < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+	if config.API.Timeout <= 0 || config.API.Retries < 0 || config.API.RetryBackoff < 0 {
+		return fmt.Errorf("api timeout must be positive, retries and retry_backoff must not be negative")
+	}
+	if config.ShutdownGracePeriod < 0 {
+		return fmt.Errorf("shutdown_grace_period must not be negative")
+	}
+	if config.Outbox.ReplayInterval <= 0 {
+		return fmt.Errorf("outbox replay_interval must be positive")
+	}
+	if config.Push.PollInterval <= 0 || config.Push.ReconnectBackoff <= 0 || config.Push.IdleTimeout <= 0 {
+		return fmt.Errorf("push poll_interval, reconnect_backoff and idle_timeout must be positive")
+	}
+	for i := range config.ConcurrencyGroups {
+		group := &config.ConcurrencyGroups[i]
+		if group.Key == "" {
+			return fmt.Errorf("concurrency group %d has no key", i+1)
+		}
+		switch group.Policy {
+		case "":
+			group.Policy = "queue"
+		case "queue", "skip", "cancel_older":
+		default:
+			return fmt.Errorf("unknown concurrency policy: %s", group.Policy)
+		}
+	}
+	switch config.Journal.RecoveryPolicy {
+	case "resume", "fail", "cancel":
+	default:
+		return fmt.Errorf("unknown journal recovery policy: %s", config.Journal.RecoveryPolicy)
+	}
+
+	return nil
+}
+
+// validatePlatforms makes sure every connection has a unique name and a valid pipeline
+func validatePlatforms(platforms []PlatformConfig) error {
+	names := make(map[string]bool)
+	for i := range platforms {
+		platform := &platforms[i]
+		if platform.Name == "" {
+			return fmt.Errorf("platform %d has no name", i+1)
+		}
+		if names[platform.Name] {
+			return fmt.Errorf("platform name %s is used twice", platform.Name)
+		}
+		names[platform.Name] = true
+
+		if platform.Type == "" {
+			return fmt.Errorf("platform %s has no type", platform.Name)
+		}
+		if platform.URL == "" {
+			return fmt.Errorf("platform %s has no url", platform.Name)
+		}
+		if platform.APIKey == "" {
+			return fmt.Errorf("platform %s has no api_key", platform.Name)
+		}
+		// without a pipeline the driver of the type runs its default
+		if len(platform.Pipeline) == 0 {
+			continue
 		}
-		if config.ExFlow.URL == "" {
-			return fmt.Errorf("exflow URL is required")
+		if err := validatePipeline(platform.Name, platform.Pipeline); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
+// validatePipeline makes sure every step has a plugin and the flow is loaded by collect_data
+func validatePipeline(platform string, pipeline []PipelineStepConfig) error {
+	collectsData := false
+	for i, step := range pipeline {
+		if step.Plugin == "" {
+			return fmt.Errorf("%s pipeline step %d has no plugin", platform, i+1)
+		}
+		for _, param := range step.Params {
+			if param.Key == "" {
+				return fmt.Errorf("%s pipeline step %s has a param without key", platform, step.Plugin)
+			}
+		}
+		if step.Plugin == PipelineCollectData {
+			collectsData = true
+		}
+	}
+	if !collectsData {
+		return fmt.Errorf("%s pipeline must contain the %s step", platform, PipelineCollectData)
+	}
 	return nil
 }
 
@@ -186,35 +487,74 @@ func (cm *ConfigurationManager) validateConfig(config *Config) error {
 func (cm *ConfigurationManager) GetConfig() Config {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
-	return *cm.config
+	config := *cm.config
+	// runner IDs of the connections are updated after registering, callers keep their copy
+	config.Platforms = append([]PlatformConfig(nil), cm.config.Platforms...)
+	return config
 }
 
-// UpdateRunnerID updates the runner ID in the configuration for both Alertflow and ExFlow
+// UpdateRunnerID updates the runner ID of a platform connection
 func (cm *ConfigurationManager) UpdateRunnerID(platform, id string) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
-	if platform == "alertflow" {
-		cm.config.Alertflow.RunnerID = id
-	}
-	if platform == "exflow" {
-		cm.config.ExFlow.RunnerID = id
+	for i := range cm.config.Platforms {
+		if cm.config.Platforms[i].Name == platform {
+			cm.config.Platforms[i].RunnerID = id
+		}
 	}
 }
 
-// GetRunnerIDs returns the current runner IDs for both Alertflow and ExFlow
+// GetRunnerID returns the current runner ID of a platform connection
 func (cm *ConfigurationManager) GetRunnerID(platform string) string {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
-	if platform == "alertflow" {
-		return cm.config.Alertflow.RunnerID
-	}
-	if platform == "exflow" {
-		return cm.config.ExFlow.RunnerID
+	if connection, ok := cm.config.Platform(platform); ok {
+		return connection.RunnerID
 	}
 
 	return ""
 }
 
+// Platform returns the connection with the name
+func (c Config) Platform(name string) (PlatformConfig, bool) {
+	for _, platform := range c.Platforms {
+		if platform.Name == name {
+			return platform, true
+		}
+	}
+	return PlatformConfig{}, false
+}
+
+// ForPlatform returns a copy of the config which only holds the connection with the
+// name. Plugins get it for their requests, so they never see the API keys of other
+// connections, and helpers which do not take a platform, like the alert helpers,
+// use the connection of the request. The alertflow and exflow blocks are only kept
+// if they are that connection.
+func (c Config) ForPlatform(name string) Config {
+	connection, ok := c.Platform(name)
+	c.Platforms = nil
+	if ok {
+		c.Platforms = []PlatformConfig{connection}
+	}
+
+	if !ok || c.Alertflow.URL != connection.URL || c.Alertflow.APIKey != connection.APIKey {
+		c.Alertflow = AlertflowConfig{}
+	}
+	if !ok || c.ExFlow.URL != connection.URL || c.ExFlow.APIKey != connection.APIKey {
+		c.ExFlow = exflowConfig{}
+	}
+	return c
+}
+
+// WithoutPlatforms returns a copy of the config without any connection, e.g. for
+// plugin calls which do not belong to a platform
+func (c Config) WithoutPlatforms() Config {
+	c.Platforms = nil
+	c.Alertflow = AlertflowConfig{}
+	c.ExFlow = exflowConfig{}
+	return c
+}
+
 // ReloadConfig reloads the configuration from the file
 func (cm *ConfigurationManager) ReloadConfig() error {
 	return cm.LoadConfig(cm.viper.ConfigFileUsed())
@@ -3,6 +3,7 @@ package endpoints
 import (
 	"io"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/v1Flows/runner/config"
 	"github.com/v1Flows/runner/pkg/plugins"
@@ -12,9 +13,28 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func RegisterEndpoints(loadedPluginEndpoints []shared_models.Plugin) (endpoints []shared_models.Endpoint) {
+// draining is set once the runner shuts down and no longer accepts alerts
+var draining atomic.Bool
+
+// StopAcceptingAlerts rejects all further alerts with 503 Service Unavailable
+func StopAcceptingAlerts() {
+	draining.Store(true)
+}
+
+// Prefix returns the path prefix of the alert endpoints of a platform. The
+// endpoints are only prefixed with the platform name if several platforms receive alerts.
+func Prefix(alertPlatforms []string, platform string) string {
+	if len(alertPlatforms) < 2 {
+		return ""
+	}
+	return "/" + platform
+}
+
+func RegisterEndpoints(loadedPluginEndpoints []shared_models.Plugin, prefix string) (endpoints []shared_models.Endpoint) {
 	for _, plugin := range loadedPluginEndpoints {
-		endpoints = append(endpoints, plugin.Endpoint)
+		endpoint := plugin.Endpoint
+		endpoint.Path = prefix + endpoint.Path
+		endpoints = append(endpoints, endpoint)
 	}
 
 	if len(endpoints) == 0 {
@@ -24,44 +44,65 @@ func RegisterEndpoints(loadedPluginEndpoints []shared_models.Plugin) (endpoints
 	return endpoints
 }
 
-func InitEndpointRouter(cfg config.Config, router *gin.Engine, platform string, endpointPlugins []shared_models.Plugin, loadedPlugins map[string]plugins.Plugin) {
-	log.Info("Open Alert Port: ", cfg.Endpoints.Port)
-
+// InitEndpointRouter registers the alert endpoints of the platforms, they are served by Serve
+func InitEndpointRouter(cfg config.Config, router *gin.Engine, alertPlatforms []string, endpointPlugins []shared_models.Plugin, loadedPlugins map[string]plugins.Plugin) {
 	alert := router.Group("/alert")
-	for _, plugin := range endpointPlugins {
-		log.Infof("Open %s Endpoint at /alert%s", plugin.Name, plugin.Endpoint.Path)
-		alert.POST(plugin.Endpoint.Path, func(c *gin.Context) {
-			log.Info("Received Alert for: ", plugin.Name)
+	alert.Use(func(c *gin.Context) {
+		if draining.Load() {
+			c.AbortWithStatusJSON(503, gin.H{
+				"error": "Runner is shutting down",
+			})
+			return
+		}
+		c.Next()
+	})
+	for _, platform := range alertPlatforms {
+		for _, plugin := range endpointPlugins {
+			openEndpoint(cfg, alert, Prefix(alertPlatforms, platform), platform, plugin, loadedPlugins)
+		}
+	}
+}
+
+// Serve serves the routes of the router, all routes have to be registered before
+func Serve(cfg config.Config, router *gin.Engine) {
+	log.Info("Open Port: ", cfg.Endpoints.Port)
+	if err := router.Run(":" + strconv.Itoa(cfg.Endpoints.Port)); err != nil {
+		log.Errorf("Failed to serve endpoints: %v", err)
+	}
+}
 
-			bodyBytes, err := io.ReadAll(c.Request.Body)
-			if err != nil {
-				log.Error("Error reading request body: ", err)
-				c.JSON(500, gin.H{
-					"error": "Error reading request body",
-				})
-				return
-			}
+// openEndpoint routes the alerts of an endpoint plugin to the platform
+func openEndpoint(cfg config.Config, alert *gin.RouterGroup, prefix string, platform string, plugin shared_models.Plugin, loadedPlugins map[string]plugins.Plugin) {
+	log.Infof("Open %s Endpoint at /alert%s%s for %s", plugin.Name, prefix, plugin.Endpoint.Path, platform)
+	alert.POST(prefix+plugin.Endpoint.Path, func(c *gin.Context) {
+		log.Info("Received Alert for: ", plugin.Name)
 
-			request := plugins.EndpointRequest{
-				Config:   cfg,
-				Body:     bodyBytes,
-				Platform: platform,
-			}
+		bodyBytes, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.Error("Error reading request body: ", err)
+			c.JSON(500, gin.H{
+				"error": "Error reading request body",
+			})
+			return
+		}
 
-			res, err := loadedPlugins[plugin.Endpoint.ID].EndpointRequest(request)
-			if err != nil {
-				log.Error("Error in handling request: ", err)
-				c.JSON(500, gin.H{
-					"error": err,
-				})
-			} else {
-				log.Info("Request handled successfully")
-				c.JSON(200, gin.H{
-					"response": res,
-				})
-			}
-		})
-	}
+		request := plugins.EndpointRequest{
+			Config:   cfg.ForPlatform(platform),
+			Body:     bodyBytes,
+			Platform: platform,
+		}
 
-	router.Run(":" + strconv.Itoa(cfg.Endpoints.Port))
+		res, err := loadedPlugins[plugin.Endpoint.ID].EndpointRequest(request)
+		if err != nil {
+			log.Error("Error in handling request: ", err)
+			c.JSON(500, gin.H{
+				"error": err,
+			})
+		} else {
+			log.Info("Request handled successfully")
+			c.JSON(200, gin.H{
+				"response": res,
+			})
+		}
+	})
 }
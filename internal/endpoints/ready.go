@@ -7,6 +7,12 @@ import (
 
 func ReadyEndpoint(cfg config.Config, router *gin.Engine) {
 	router.GET("/ready", func(c *gin.Context) {
+		if draining.Load() {
+			c.JSON(503, gin.H{
+				"status": "shutting down",
+			})
+			return
+		}
 		c.JSON(200, gin.H{
 			"status": "ok",
 		})
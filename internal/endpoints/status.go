@@ -0,0 +1,13 @@
+package endpoints
+
+import (
+	"github.com/gin-gonic/gin"
+	internal_executions "github.com/v1Flows/runner/internal/executions"
+)
+
+// StatusEndpoint shows the running executions and the queue in the order it is processed
+func StatusEndpoint(router *gin.Engine, pool *internal_executions.Pool) {
+	router.GET("/status", func(c *gin.Context) {
+		c.JSON(200, pool.Status())
+	})
+}
@@ -0,0 +1,185 @@
+package internal_executions
+
+import (
+	"strconv"
+	"strings"
+
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+const runnerParamCategory = "Runner"
+
+const (
+	paramDependsOn = "depends_on"
+	paramTimeout   = "timeout"
+	paramWhen      = "when"
+	paramHook      = "hook"
+
+	paramContinueOnError = "continue_on_error"
+
+	paramInteractionTimeout = "interaction_timeout"
+	paramInteractionDefault = "interaction_default"
+
+	paramForEach            = "for_each"
+	paramForEachParallelism = "for_each_parallelism"
+
+	paramConcurrencyKey    = "concurrency_key"
+	paramConcurrencyPolicy = "concurrency_policy"
+
+	paramRetryMaxAttempts   = "retry_max_attempts"
+	paramRetryInitialDelay  = "retry_initial_delay"
+	paramRetryBackoffFactor = "retry_backoff_factor"
+	paramRetryOn            = "retry_on"
+)
+
+// runnerParams are added to every registered action. They are evaluated by the
+// runner itself and control how a flow action is scheduled.
+var runnerParams = []shared_models.Params{
+	{
+		Key:         paramDependsOn,
+		Title:       "Depends On",
+		Description: "Comma separated names or IDs of actions which have to succeed before this action starts",
+		Category:    runnerParamCategory,
+		Type:        "text",
+	},
+	{
+		Key:         paramWhen,
+		Title:       "When",
+		Description: "Condition which has to be true for the action to run, e.g. eq .Steps.ping.Status \"success\". The action is skipped otherwise",
+		Category:    runnerParamCategory,
+		Type:        "text",
+	},
+	{
+		Key:         paramHook,
+		Title:       "Hook",
+		Description: "Run the action only after the regular actions ended: on_failure, on_success or always",
+		Category:    runnerParamCategory,
+		Type:        "select",
+		Options:     []string{"", "on_failure", "on_success", "always"},
+	},
+	{
+		Key:         paramContinueOnError,
+		Title:       "Continue On Error",
+		Description: "Continue the flow if the action fails. The execution ends with successWithWarnings",
+		Category:    runnerParamCategory,
+		Type:        "boolean",
+		Default:     "false",
+	},
+	{
+		Key:         paramForEach,
+		Title:       "For Each",
+		Description: "Run the action once per item of a list. Either a comma separated list, a json array or a template like ${{ toJson .Alert.Payload.hosts }}. The item is available as ${{ .Item }}",
+		Category:    runnerParamCategory,
+		Type:        "text",
+	},
+	{
+		Key:         paramForEachParallelism,
+		Title:       "For Each Parallelism",
+		Description: "How many items of the for_each list are processed at the same time",
+		Category:    runnerParamCategory,
+		Type:        "number",
+		Default:     "1",
+	},
+	{
+		Key:         paramInteractionTimeout,
+		Title:       "Interaction Timeout",
+		Description: "How long an action waiting for an interaction waits for an answer, e.g. 30m. Waits forever if empty",
+		Category:    runnerParamCategory,
+		Type:        "text",
+	},
+	{
+		Key:         paramInteractionDefault,
+		Title:       "Interaction Default",
+		Description: "Answer used once the interaction timeout is reached: approve or reject",
+		Category:    runnerParamCategory,
+		Type:        "select",
+		Options:     []string{"reject", "approve"},
+		Default:     "reject",
+	},
+	{
+		Key:         paramConcurrencyKey,
+		Title:       "Concurrency Key",
+		Description: "Actions with the same key never run at the same time on this runner, e.g. restart-${{ .Alert.Payload.labels.service }}",
+		Category:    runnerParamCategory,
+		Type:        "text",
+	},
+	{
+		Key:         paramConcurrencyPolicy,
+		Title:       "Concurrency Policy",
+		Description: "What happens if the concurrency key is held by another execution: queue waits for it, skip skips the action and cancel_older cancels the older execution",
+		Category:    runnerParamCategory,
+		Type:        "select",
+		Options:     []string{"queue", "skip", "cancel_older"},
+		Default:     "queue",
+	},
+	{
+		Key:         paramTimeout,
+		Title:       "Timeout",
+		Description: "Maximum duration of the action, e.g. 30s or 5m. Overrides the step timeout of the runner",
+		Category:    runnerParamCategory,
+		Type:        "text",
+	},
+	{
+		Key:         paramRetryMaxAttempts,
+		Title:       "Retry Max Attempts",
+		Description: "How often the action is executed at most",
+		Category:    runnerParamCategory,
+		Type:        "number",
+		Default:     "1",
+	},
+	{
+		Key:         paramRetryInitialDelay,
+		Title:       "Retry Initial Delay",
+		Description: "Delay before the first retry, e.g. 5s",
+		Category:    runnerParamCategory,
+		Type:        "text",
+		Default:     "5s",
+	},
+	{
+		Key:         paramRetryBackoffFactor,
+		Title:       "Retry Backoff Factor",
+		Description: "Factor the delay is multiplied with after each retry",
+		Category:    runnerParamCategory,
+		Type:        "number",
+		Default:     "2",
+	},
+	{
+		Key:         paramRetryOn,
+		Title:       "Retry On",
+		Description: "Comma separated outcomes which are retried: error, failure, timeout. A timed out plugin may still be running when it is retried.",
+		Category:    runnerParamCategory,
+		Type:        "text",
+		Default:     "error,failure",
+	},
+}
+
+// actionParam returns the value of a param or its default if no value is set
+func actionParam(action shared_models.Action, key string) string {
+	for _, param := range action.Params {
+		if param.Key == key {
+			if param.Value == "" {
+				return param.Default
+			}
+			return param.Value
+		}
+	}
+	return ""
+}
+
+// actionParamList splits a comma separated param value into its trimmed entries
+func actionParamList(action shared_models.Action, key string) []string {
+	var list []string
+	for _, entry := range strings.Split(actionParam(action, key), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// actionParamBool reports whether a param is set to a true value
+func actionParamBool(action shared_models.Action, key string) bool {
+	value, err := strconv.ParseBool(actionParam(action, key))
+	return err == nil && value
+}
@@ -0,0 +1,196 @@
+package internal_executions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	concurrencyQueue       = "queue"
+	concurrencySkip        = "skip"
+	concurrencyCancelOlder = "cancel_older"
+)
+
+// errSuperseded is the cancel cause of executions canceled by a newer execution of their concurrency group
+var errSuperseded = errors.New("superseded by a newer execution of the concurrency group")
+
+// superseded reports whether the execution context was canceled by a newer execution of its concurrency group
+func superseded(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errSuperseded)
+}
+
+// errHeldByParent is returned for a key held by an execution which waits for the acquiring one, e.g. the parent of a sub flow
+var errHeldByParent = errors.New("concurrency key is held by a parent execution")
+
+// concurrencyHold is a concurrency key held by an execution. An execution can
+// acquire a key it already holds, e.g. for the flow and one of its actions.
+type concurrencyHold struct {
+	executionID string
+	count       int
+	released    chan struct{}
+}
+
+// trackedExecution is a running execution which can be canceled by a newer one
+type trackedExecution struct {
+	createdAt time.Time
+	cancel    context.CancelCauseFunc
+}
+
+// concurrencyGroups makes sure only one execution of the runner holds a concurrency key at a time
+type concurrencyGroups struct {
+	mu         sync.Mutex
+	holds      map[string]*concurrencyHold
+	executions map[string]trackedExecution
+	// parents maps sub flow executions to the executions waiting for them
+	parents map[string]string
+}
+
+var concurrency = &concurrencyGroups{
+	holds:      make(map[string]*concurrencyHold),
+	executions: make(map[string]trackedExecution),
+	parents:    make(map[string]string),
+}
+
+// track registers a running execution so the cancel_older policy can cancel it
+func (g *concurrencyGroups) track(execution shared_models.Executions, cancel context.CancelCauseFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.executions[execution.ID.String()] = trackedExecution{
+		createdAt: execution.CreatedAt,
+		cancel:    cancel,
+	}
+}
+
+func (g *concurrencyGroups) untrack(executionID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.executions, executionID)
+}
+
+// nest registers an execution the parent waits for, e.g. a sub flow
+func (g *concurrencyGroups) nest(executionID string, parentID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.parents[executionID] = parentID
+}
+
+func (g *concurrencyGroups) unnest(executionID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.parents, executionID)
+}
+
+// heldByParent reports whether the holder waits for the execution, directly or
+// through further sub flows. g.mu has to be held.
+func (g *concurrencyGroups) heldByParent(executionID string, holderID string) bool {
+	for parentID, ok := g.parents[executionID]; ok; parentID, ok = g.parents[parentID] {
+		if parentID == holderID {
+			return true
+		}
+	}
+	return false
+}
+
+// acquire takes the key for the execution. If another execution holds the key
+// the policy decides: queue waits until it is released, skip returns false right
+// away and cancel_older cancels the holder and waits for it to release the key.
+// An execution which is older than the holder is skipped with cancel_older.
+// While waiting the execution lends its pool slot to others. A key held by a
+// parent execution, which would never be released, is rejected with errHeldByParent.
+func (g *concurrencyGroups) acquire(ctx context.Context, key string, execution shared_models.Executions, policy string) (bool, error) {
+	executionID := execution.ID.String()
+
+	for {
+		g.mu.Lock()
+		hold, ok := g.holds[key]
+		if !ok {
+			g.holds[key] = &concurrencyHold{
+				executionID: executionID,
+				count:       1,
+				released:    make(chan struct{}),
+			}
+			g.mu.Unlock()
+			return true, nil
+		}
+		if hold.executionID == executionID {
+			hold.count++
+			g.mu.Unlock()
+			return true, nil
+		}
+		if g.heldByParent(executionID, hold.executionID) {
+			g.mu.Unlock()
+			return false, fmt.Errorf("%w: %s holds %s", errHeldByParent, hold.executionID, key)
+		}
+
+		switch policy {
+		case concurrencySkip:
+			g.mu.Unlock()
+			return false, nil
+		case concurrencyCancelOlder:
+			holder, tracked := g.executions[hold.executionID]
+			if tracked && holder.createdAt.After(execution.CreatedAt) {
+				g.mu.Unlock()
+				return false, nil
+			}
+			if tracked {
+				log.Infof("Execution %s cancels execution %s holding concurrency key %s", executionID, hold.executionID, key)
+				holder.cancel(errSuperseded)
+			}
+		}
+
+		released := hold.released
+		g.mu.Unlock()
+
+		log.Infof("Execution %s waits for concurrency key %s held by execution %s", executionID, key, hold.executionID)
+		wait := func() {
+			select {
+			case <-released:
+			case <-ctx.Done():
+			}
+		}
+		// a waiting execution does not keep other executions from their slot
+		if pool, ok := poolFromContext(ctx); ok {
+			pool.lend(wait)
+		} else {
+			wait()
+		}
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+	}
+}
+
+// release gives up a key acquired by the execution
+func (g *concurrencyGroups) release(key string, executionID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	hold, ok := g.holds[key]
+	if !ok || hold.executionID != executionID {
+		return
+	}
+
+	hold.count--
+	if hold.count == 0 {
+		delete(g.holds, key)
+		close(hold.released)
+	}
+}
+
+// flowConcurrencyGroup returns the first configured concurrency group which matches the flow by ID or name
+func flowConcurrencyGroup(cfg config.Config, flow shared_models.Flows) (config.ConcurrencyGroupConfig, bool) {
+	for _, group := range cfg.ConcurrencyGroups {
+		if group.Flow == "" || group.Flow == flow.ID.String() || group.Flow == flow.Name {
+			return group, true
+		}
+	}
+	return config.ConcurrencyGroupConfig{}, false
+}
@@ -0,0 +1,77 @@
+package internal_executions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/v1Flows/runner/config"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+func newTestConcurrencyGroups() *concurrencyGroups {
+	return &concurrencyGroups{
+		holds:      make(map[string]*concurrencyHold),
+		executions: make(map[string]trackedExecution),
+		parents:    make(map[string]string),
+	}
+}
+
+func TestConcurrencyRejectsKeyOfParent(t *testing.T) {
+	groups := newTestConcurrencyGroups()
+	parent := shared_models.Executions{ID: uuid.New()}
+	child := shared_models.Executions{ID: uuid.New()}
+	grandchild := shared_models.Executions{ID: uuid.New()}
+
+	if acquired, err := groups.acquire(context.Background(), "k", parent, concurrencyQueue); !acquired || err != nil {
+		t.Fatalf("parent did not acquire the key: %v", err)
+	}
+	// the parent may acquire its own key again, e.g. for one of its actions
+	if acquired, err := groups.acquire(context.Background(), "k", parent, concurrencyQueue); !acquired || err != nil {
+		t.Fatalf("parent did not acquire its key again: %v", err)
+	}
+
+	groups.nest(child.ID.String(), parent.ID.String())
+	groups.nest(grandchild.ID.String(), child.ID.String())
+	for _, execution := range []shared_models.Executions{child, grandchild} {
+		if _, err := groups.acquire(context.Background(), "k", execution, concurrencyQueue); !errors.Is(err, errHeldByParent) {
+			t.Errorf("got %v for a key held by a parent, want errHeldByParent", err)
+		}
+	}
+}
+
+func TestConcurrencyQueueLendsSlot(t *testing.T) {
+	groups := newTestConcurrencyGroups()
+	pool := newTestPool(config.Config{}, 1)
+	holder := shared_models.Executions{ID: uuid.New()}
+	waiter := shared_models.Executions{ID: uuid.New()}
+
+	if acquired, err := groups.acquire(pool.ctx, "k", holder, concurrencyQueue); !acquired || err != nil {
+		t.Fatalf("holder did not acquire the key: %v", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		acquired, err := groups.acquire(pool.ctx, "k", waiter, concurrencyQueue)
+		if err == nil && !acquired {
+			err = errors.New("key not acquired")
+		}
+		result <- err
+	}()
+
+	// the waiting execution gives its slot to other executions
+	waitFor(t, "the slot to be lent", func() bool { return pool.Free() == 2 })
+
+	groups.release("k", holder.ID.String())
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter did not acquire the released key")
+	}
+	waitFor(t, "the slot to be returned", func() bool { return pool.Free() == 1 })
+}
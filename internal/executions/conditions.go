@@ -0,0 +1,69 @@
+package internal_executions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// templateFuncs are available in conditions and param templates in addition to the template builtins
+var templateFuncs = template.FuncMap{
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"hasSuffix": strings.HasSuffix,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"matches": func(pattern string, value string) (bool, error) {
+		return regexp.MatchString(pattern, value)
+	},
+	"toJson": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+// evaluateCondition evaluates the when param of an action. The expression uses
+// the template syntax and may be written with or without the surrounding ${{ }},
+// e.g. eq .Steps.ping.Status "success". Actions without condition always run.
+func evaluateCondition(action shared_models.Action, data templateData) (bool, error) {
+	return evaluateExpression(paramWhen, actionParam(action, paramWhen), flowTemplateDelim, data)
+}
+
+// evaluateExpression evaluates a condition whose templates start with left. An empty expression is true.
+func evaluateExpression(name string, expression string, left string, data templateData) (bool, error) {
+	expression = strings.TrimSpace(expression)
+	if expression == "" {
+		return true, nil
+	}
+	if !strings.Contains(expression, left) {
+		expression = left + " " + expression + " }}"
+	}
+
+	tmpl, err := parseTemplate(name, expression, left)
+	if err != nil {
+		return false, err
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return false, err
+	}
+
+	result := strings.TrimSpace(rendered.String())
+	if result == "" {
+		return false, nil
+	}
+
+	run, err := strconv.ParseBool(result)
+	if err != nil {
+		return false, fmt.Errorf("condition has to evaluate to true or false, got %q", result)
+	}
+
+	return run, nil
+}
@@ -0,0 +1,61 @@
+package internal_executions
+
+import (
+	"strings"
+
+	"github.com/v1Flows/runner/config"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// dryRun reports whether the executions of the flow are dry runs. The flow is
+// unknown while the initial steps are processed, only the global setting applies there.
+func dryRun(cfg config.Config, flow shared_models.Flows) bool {
+	if cfg.DryRun.Enabled {
+		return true
+	}
+
+	for _, ref := range cfg.DryRun.Flows {
+		if ref != "" && (ref == flow.ID.String() || ref == flow.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dryRunMessage lists the resolved params of the action. Values of password params are masked.
+// supported tells whether the plugin is called with the dry run flag or not at all.
+func dryRunMessage(action shared_models.Action, supported bool) shared_models.Message {
+	content := "The plugin is asked to make no side effects"
+	if !supported {
+		content = "The plugin is not called, it does not support dry runs"
+	}
+	message := shared_models.Message{
+		Title: "Dry Run",
+		Lines: []shared_models.Line{
+			{
+				Content: content,
+				Color:   "warning",
+			},
+		},
+	}
+
+	for _, param := range action.Params {
+		value := param.Value
+		if value == "" {
+			value = param.Default
+		}
+		if value == "" {
+			continue
+		}
+		if strings.EqualFold(param.Type, "password") {
+			value = "********"
+		}
+
+		message.Lines = append(message.Lines, shared_models.Line{
+			Content: param.Key + ": " + value,
+		})
+	}
+
+	return message
+}
@@ -1,121 +1,97 @@
 package internal_executions
 
 import (
-	"encoding/json"
-	"io"
-	"net/http"
 	"time"
 
-	af_models "github.com/v1Flows/alertFlow/services/backend/pkg/models"
-	ef_models "github.com/v1Flows/exFlow/services/backend/pkg/models"
 	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/api"
 	"github.com/v1Flows/runner/pkg/platform"
-	platformfn "github.com/v1Flows/runner/pkg/platform"
-	"github.com/v1Flows/runner/pkg/plugins"
-	shared_models "github.com/v1Flows/shared-library/pkg/models"
 
 	log "github.com/sirupsen/logrus"
 )
 
-type IncomingSharedExecutions struct {
-	Executions []shared_models.Executions `json:"executions"`
-}
+// pollInterval is how often pending executions are polled without a connected event stream
+var pollInterval = 10 * time.Second
 
-type IncomingAfExecutions struct {
-	Executions []af_models.Executions `json:"executions"`
-}
+func GetPendingExecutions(targetPlatform string, cfg config.Config, pool *Pool) {
+	driver, err := platform.Get(cfg, targetPlatform)
+	if err != nil {
+		log.Fatal(err)
+	}
+	runnerID := config.GetInstance().GetRunnerID(targetPlatform)
 
-type IncomingEfExecutions struct {
-	Executions []ef_models.Executions `json:"executions"`
+	pollExecutions(cfg, driver, runnerID, pool)
 }
 
-func GetPendingExecutions(targetPlatform string, cfg config.Config, actions []shared_models.Action, loadedPlugins map[string]plugins.Plugin) {
-	url, apiKey, runnerID := platform.GetPlatformConfig(targetPlatform, cfg)
-
-	client := http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			DisableKeepAlives: true,
-		},
+// pollExecutions feeds the pending executions of the platform into the pool until it is closed
+func pollExecutions(cfg config.Config, driver platform.Platform, runnerID string, pool *Pool) {
+	targetPlatform := driver.Name()
+
+	// pushed executions are received over the event stream, polling takes over while it is not connected
+	var delivery *pushDelivery
+	var pollNow <-chan struct{}
+	if cfg.Push.Enabled {
+		delivery = newPushDelivery(targetPlatform)
+		pollNow = delivery.poll
+		go delivery.run(cfg, driver, runnerID, pool)
 	}
 
-	parsedUrl := url + "/api/v1/runners/" + runnerID + "/executions/pending"
-	req, err := http.NewRequest("GET", parsedUrl, nil)
-	if err != nil {
-		log.Fatalf("Failed to create request: %v", err)
-	}
-	req.Header.Set("Authorization", apiKey)
-	ticker := time.NewTicker(time.Second * 10)
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
-	for range ticker.C {
-		var resp *http.Response
-		var err error
-		for i := 0; i < 3; i++ {
-			resp, err = client.Do(req)
-			if err != nil {
-				log.Errorf("Failed to send request: %v", err)
-				time.Sleep(5 * time.Second) // Add delay before retrying
-				continue
-			}
 
-			if resp.StatusCode != 200 {
-				log.Errorf("Failed to get waiting executions from %s API: %s, attempt %d", targetPlatform, parsedUrl, i+1)
-				time.Sleep(5 * time.Second) // Add delay before retrying
-				continue
-			}
+	var lastPoll time.Time
+	// backlog is set if executions are waiting on the platform for a free slot
+	backlog := false
+	for {
+		requested := false
+		select {
+		case <-ticker.C:
+		case <-pollNow:
+			requested = true
+		}
+
+		// the pool is closed once the runner shuts down
+		if pool.Closed() {
+			log.Infof("Stop polling %s for pending executions", targetPlatform)
+			return
+		}
 
-			log.Debugf("Executions received from %s API: %s", targetPlatform, parsedUrl)
+		// while the stream is connected polling only catches up on executions which were not pushed
+		if delivery != nil && delivery.connected.Load() && !requested && !backlog && time.Since(lastPoll) < cfg.Push.PollInterval {
+			continue
+		}
+
+		// dont claim new executions while all slots are taken
+		if pool.Free() <= 0 {
+			log.Debugf("All execution slots are busy, skip polling %s", targetPlatform)
+			backlog = backlog || requested
+			continue
+		}
 
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				log.Error(err)
-				time.Sleep(5 * time.Second) // Add delay before retrying
+		lastPoll = time.Now()
+		pending, err := driver.PendingExecutions(cfg, runnerID)
+		if err != nil {
+			// running executions keep going while the platform is unreachable, the next tick polls again
+			if api.Unreachable(err) {
+				log.Warnf("Failed to get waiting executions from %s API, it is unreachable: %v", targetPlatform, err)
 				continue
 			}
-			resp.Body.Close() // Close the body after reading
-
-			if targetPlatform == "alertflow" {
-				var executions IncomingAfExecutions
-				err := json.Unmarshal(body, &executions)
-				if err != nil {
-					log.Error(err)
-					continue
-				}
-
-				var sharedExecutions IncomingSharedExecutions
-				err = json.Unmarshal(body, &sharedExecutions)
-				if err != nil {
-					log.Error(err)
-					continue
-				}
-
-				for index, execution := range executions.Executions {
-					// Save platform information for the execution
-					platformfn.SetPlatformForExecution(execution.ID.String(), targetPlatform)
-
-					startProcessing(targetPlatform, cfg, actions, loadedPlugins, sharedExecutions.Executions[index], execution.AlertID)
-				}
-			}
+			log.Fatalf("Failed to get waiting executions from %s API: %v", targetPlatform, err)
+		}
 
-			if targetPlatform == "exflow" {
-				var executions IncomingSharedExecutions
-				err := json.Unmarshal(body, &executions)
-				if err != nil {
-					log.Error(err)
-					continue
-				}
+		log.Debugf("Executions received from %s API", targetPlatform)
 
-				for _, execution := range executions.Executions {
-					// Save platform information for the execution
-					platformfn.SetPlatformForExecution(execution.ID.String(), targetPlatform)
+		var batch []queuedExecution
+		for _, item := range pending {
+			// Save platform information for the execution
+			platform.SetPlatformForExecution(item.Execution.ID.String(), targetPlatform)
 
-					startProcessing(targetPlatform, cfg, actions, loadedPlugins, execution, "")
-				}
-			}
-
-		}
-		if resp.StatusCode != 200 {
-			log.Fatalf("Failed to get waiting executions from %s API after 3 attempts: %s", targetPlatform, parsedUrl)
+			batch = append(batch, queuedExecution{
+				platform:  targetPlatform,
+				execution: item.Execution,
+				alertID:   item.AlertID,
+			})
 		}
+		backlog = !pool.enqueue(batch)
 	}
 }
@@ -0,0 +1,55 @@
+package internal_executions
+
+import (
+	"context"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// hookTimeout bounds the hooks of an execution if no execution_timeout is configured
+const hookTimeout = 10 * time.Minute
+
+const (
+	hookOnFailure = "on_failure"
+	hookOnSuccess = "on_success"
+	hookAlways    = "always"
+)
+
+// actionHook returns the hook group of an action or an empty string for regular actions
+func actionHook(action shared_models.Action) string {
+	hook := actionParam(action, paramHook)
+	switch hook {
+	case "", hookOnFailure, hookOnSuccess, hookAlways:
+		return hook
+	default:
+		log.Warnf("Action %s has unknown hook %s, it is executed as regular action", action.Name, hook)
+		return ""
+	}
+}
+
+// hookGroups returns the hook groups which run after the regular actions ended with the given status
+func hookGroups(status string) []string {
+	switch status {
+	case "success", "successWithWarnings":
+		return []string{hookOnSuccess, hookAlways}
+	case "noPatternMatch":
+		return []string{hookAlways}
+	default:
+		return []string{hookOnFailure, hookAlways}
+	}
+}
+
+// hookContext returns the context of the hooks of an execution. It is independent
+// of the execution context, so hooks run after a timeout or cancellation, but it is
+// canceled on shutdown and limited to execution_timeout or hookTimeout.
+func hookContext(parent context.Context, cfg config.Config) (context.Context, context.CancelFunc) {
+	timeout := cfg.ExecutionTimeout
+	if timeout <= 0 {
+		timeout = hookTimeout
+	}
+	return context.WithTimeout(parent, timeout)
+}
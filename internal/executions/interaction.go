@@ -0,0 +1,144 @@
+package internal_executions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/executions"
+	"github.com/v1Flows/runner/pkg/plugins"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const interactionPollInterval = 5 * time.Second
+
+// awaitInteraction sets the execution to interactionWaiting and polls the step
+// until it got approved or rejected. Without an answer within interaction_timeout
+// the interaction_default answer is used. A rejection cancels the execution.
+func awaitInteraction(ctx context.Context, cfg config.Config, execution shared_models.Executions, step shared_models.ExecutionSteps, targetPlatform string, res plugins.Response) (plugins.Response, bool, error) {
+	var timeout time.Duration
+	if value := actionParam(step.Action, paramInteractionTimeout); value != "" {
+		var err error
+		timeout, err = time.ParseDuration(value)
+		if err != nil {
+			return failStep(cfg, execution, step, targetPlatform, "Invalid action settings", fmt.Errorf("invalid interaction timeout: %w", err))
+		}
+	}
+
+	answer := actionParam(step.Action, paramInteractionDefault)
+	if answer != "" && answer != "approve" && answer != "reject" {
+		return failStep(cfg, execution, step, targetPlatform, "Invalid action settings", fmt.Errorf("%s must be approve or reject", paramInteractionDefault))
+	}
+
+	// the plugin wrote its messages to the step on its own, they are kept
+	step = currentStep(cfg, execution, step, targetPlatform)
+	step.Status = "interactionWaiting"
+	step.Interactive = true
+	if err := executions.UpdateStep(cfg, execution.ID.String(), step, targetPlatform); err != nil {
+		log.Error(err)
+		return plugins.Response{}, false, err
+	}
+	executions.SetToInteractionRequired(cfg, execution, targetPlatform)
+
+	log.Infof("Step %s waits for an interaction", step.ID)
+
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	ticker := time.NewTicker(interactionPollInterval)
+	defer ticker.Stop()
+
+	// a pushed interaction is checked right away
+	events, unsubscribe := pushed.subscribe(execution.ID.String())
+	defer unsubscribe()
+
+	var line shared_models.Line
+	for line.Content == "" {
+		select {
+		case <-ctx.Done():
+			return interruptStep(ctx, cfg, execution, step, targetPlatform, 0, ctx.Err())
+		case <-deadline:
+			if answer == "" {
+				answer = "reject"
+			}
+			step = currentStep(cfg, execution, step, targetPlatform)
+			line = shared_models.Line{Content: fmt.Sprintf("No interaction within %s, continue with default answer: %s", timeout, answer)}
+		case <-ticker.C:
+		case <-events:
+		}
+
+		if line.Content == "" {
+			current, err := executions.GetStep(cfg, execution.ID.String(), step.ID.String(), targetPlatform)
+			if err != nil {
+				log.Warnf("Failed to check interaction of step %s: %v", step.ID, err)
+				continue
+			}
+			if !current.Interacted {
+				continue
+			}
+
+			step = current
+			if current.InteractionApproved {
+				answer = "approve"
+				line = shared_models.Line{Content: "Approved by " + current.InteractedBy, Color: "success"}
+			} else {
+				answer = "reject"
+				line = shared_models.Line{Content: "Rejected by " + current.InteractedBy, Color: "danger"}
+			}
+		}
+	}
+
+	step.Messages = append(step.Messages, shared_models.Message{
+		Title: "Interaction",
+		Lines: []shared_models.Line{line},
+	})
+	step.FinishedAt = time.Now()
+
+	if res.Data == nil {
+		res.Data = make(map[string]interface{})
+	}
+	res.Data["interaction"] = answer
+
+	if answer == "approve" {
+		step.Status = "success"
+		step.InteractionApproved = true
+		res.Data["status"] = "success"
+		res.Success = true
+	} else {
+		step.Status = "canceled"
+		step.InteractionRejected = true
+		step.CanceledBy = "Runner"
+		step.CanceledAt = time.Now()
+		res.Data["status"] = "canceled"
+	}
+
+	if err := executions.UpdateStep(cfg, execution.ID.String(), step, targetPlatform); err != nil {
+		log.Error(err)
+		return plugins.Response{}, false, err
+	}
+
+	if answer == "approve" {
+		executions.SetToRunning(cfg, execution, targetPlatform)
+	}
+
+	log.Infof("Step %s got interaction answer: %s", step.ID, answer)
+
+	return res, res.Success, nil
+}
+
+// currentStep reads the step back from the platform, the local copy is used if that fails
+func currentStep(cfg config.Config, execution shared_models.Executions, step shared_models.ExecutionSteps, targetPlatform string) shared_models.ExecutionSteps {
+	current, err := executions.GetStep(cfg, execution.ID.String(), step.ID.String(), targetPlatform)
+	if err != nil {
+		log.Warnf("Failed to read step %s, continue with the local copy: %v", step.ID, err)
+		return step
+	}
+	return current
+}
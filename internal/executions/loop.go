@@ -0,0 +1,199 @@
+package internal_executions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	af_models "github.com/v1Flows/alertFlow/services/backend/pkg/models"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/executions"
+	"github.com/v1Flows/runner/pkg/plugins"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// loopItems resolves the for_each param of an action. It accepts a json array,
+// a comma separated list or a template which renders to one of both.
+func loopItems(action shared_models.Action, data templateData) ([]interface{}, error) {
+	value := strings.TrimSpace(actionParam(action, paramForEach))
+
+	if strings.Contains(value, flowTemplateDelim) {
+		tmpl, err := parseTemplate(paramForEach, value, flowTemplateDelim)
+		if err != nil {
+			return nil, err
+		}
+
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return nil, err
+		}
+		value = strings.TrimSpace(rendered.String())
+	}
+
+	var items []interface{}
+	if strings.HasPrefix(value, "[") {
+		if err := json.Unmarshal([]byte(value), &items); err != nil {
+			return nil, fmt.Errorf("for_each is no valid json array: %w", err)
+		}
+		return items, nil
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// processLoopStep executes the action of a step with for_each once per item.
+// Every item gets its own child step, the step itself reflects the result of all
+// items. Items are processed with for_each_parallelism at the same time and no
+// further items are started once an item did not succeed.
+func processLoopStep(ctx context.Context, cfg config.Config, workspace string, actions []shared_models.Action, loadedPlugins map[string]plugins.Plugin, flow shared_models.Flows, flowBytes []byte, alert af_models.Alerts, outputs *executionOutputs, steps []shared_models.ExecutionSteps, step shared_models.ExecutionSteps, execution shared_models.Executions, targetPlatform string) (plugins.Response, bool, error) {
+	items, err := loopItems(step.Action, outputs.templateData(flow, alert, execution))
+	if err != nil {
+		return failStep(cfg, execution, step, targetPlatform, "Resolving the for_each items failed", err)
+	}
+
+	parallelism, err := strconv.Atoi(actionParam(step.Action, paramForEachParallelism))
+	if err != nil || parallelism < 1 {
+		return failStep(cfg, execution, step, targetPlatform, "Invalid action settings", fmt.Errorf("%s must be a number greater than 0", paramForEachParallelism))
+	}
+
+	step.Status = "running"
+	step.StartedAt = time.Now()
+	step.RunnerID = execution.RunnerID
+	step.Messages = append(step.Messages, shared_models.Message{
+		Title: "Loop",
+		Lines: []shared_models.Line{
+			{
+				Content: fmt.Sprintf("Processing %d items, %d at a time", len(items), parallelism),
+			},
+		},
+	})
+
+	if err := executions.UpdateStep(cfg, execution.ID.String(), step, targetPlatform); err != nil {
+		log.Error(err)
+		return plugins.Response{}, false, err
+	}
+
+	results := make([]stepResult, len(items))
+	started := 0
+	failed := false
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallelism)
+	for i, item := range items {
+		slots <- struct{}{}
+
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			<-slots
+			break
+		}
+
+		started++
+		wg.Add(1)
+		go func(i int, item interface{}) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result := processLoopItem(ctx, cfg, workspace, actions, loadedPlugins, flow, flowBytes, alert, outputs, steps, step, execution, targetPlatform, item, i)
+			results[i] = result
+
+			if status := result.status(); status != "success" && status != "skipped" {
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return interruptStep(ctx, cfg, execution, step, targetPlatform, 0, ctx.Err())
+	}
+
+	counts := make(map[string]int)
+	var data []interface{}
+	for _, result := range results[:started] {
+		counts[result.status()]++
+		data = append(data, result.res.Data)
+	}
+
+	success := started == len(items) && counts["success"]+counts["skipped"] == started
+	color := "success"
+	if !success {
+		color = "danger"
+	}
+
+	step.Messages = append(step.Messages, shared_models.Message{
+		Title: "Loop Result",
+		Lines: []shared_models.Line{
+			{
+				Content: fmt.Sprintf("Processed %d of %d items", started, len(items)),
+				Color:   color,
+			},
+			{
+				Content: fmt.Sprintf("Succeeded: %d, Skipped: %d, Failed: %d", counts["success"], counts["skipped"], started-counts["success"]-counts["skipped"]),
+				Color:   color,
+			},
+		},
+	})
+	step.Status = "success"
+	if !success {
+		step.Status = "error"
+	}
+	step.FinishedAt = time.Now()
+
+	if err := executions.UpdateStep(cfg, execution.ID.String(), step, targetPlatform); err != nil {
+		log.Error(err)
+		return plugins.Response{}, false, err
+	}
+
+	return plugins.Response{
+		Data: map[string]interface{}{
+			"items":   items,
+			"results": data,
+		},
+		Success: success,
+	}, success, nil
+}
+
+// processLoopItem creates the child step for a single item and processes it
+func processLoopItem(ctx context.Context, cfg config.Config, workspace string, actions []shared_models.Action, loadedPlugins map[string]plugins.Plugin, flow shared_models.Flows, flowBytes []byte, alert af_models.Alerts, outputs *executionOutputs, steps []shared_models.ExecutionSteps, parent shared_models.ExecutionSteps, execution shared_models.Executions, targetPlatform string, item interface{}, index int) stepResult {
+	child := shared_models.ExecutionSteps{
+		Action:      parent.Action,
+		ExecutionID: execution.ID.String(),
+		ParentID:    parent.ID.String(),
+		Status:      "pending",
+		CreatedAt:   time.Now(),
+	}
+	child.Action.Name = fmt.Sprintf("%s [%v]", parent.Action.Name, item)
+
+	created, err := executions.SendStep(cfg, execution, child, targetPlatform)
+	if err != nil {
+		return stepResult{err: err}
+	}
+	child.ID = created.ID
+
+	itemOutputs := outputs.withItem(item, index)
+	res, success, err := processStep(ctx, cfg, workspace, actions, loadedPlugins, flow, flowBytes, alert, itemOutputs, steps, child, execution)
+	result := stepResult{res: res, success: success, err: err}
+	itemOutputs.record(child, result)
+
+	return result
+}
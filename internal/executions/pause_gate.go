@@ -0,0 +1,72 @@
+package internal_executions
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pauseGate holds back new steps while an execution is paused
+type pauseGate struct {
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Time
+	resumed  chan struct{}
+}
+
+func newPauseGate() *pauseGate {
+	return &pauseGate{}
+}
+
+// pause closes the gate. It returns false if the gate was already closed.
+func (g *pauseGate) pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.paused {
+		return false
+	}
+	g.paused = true
+	g.pausedAt = time.Now()
+	g.resumed = make(chan struct{})
+	return true
+}
+
+// resume opens the gate again. The running status was observed at seenAt, older
+// observations than the pause itself are ignored. It returns false if nothing changed.
+func (g *pauseGate) resume(seenAt time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.paused || seenAt.Before(g.pausedAt) {
+		return false
+	}
+	g.paused = false
+	close(g.resumed)
+	return true
+}
+
+// isPaused reports whether the gate is closed
+func (g *pauseGate) isPaused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// wait blocks until the gate is open or ctx is done
+func (g *pauseGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	paused, resumed := g.paused, g.resumed
+	g.mu.Unlock()
+
+	if !paused {
+		return ctx.Err()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+		return nil
+	}
+}
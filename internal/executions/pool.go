@@ -0,0 +1,336 @@
+package internal_executions
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/internal/outbox"
+	"github.com/v1Flows/runner/pkg/plugins"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// errShutdown is the cancel cause of executions still running when the runner shuts down
+var errShutdown = errors.New("runner shut down")
+
+// shutDown reports whether the execution context was canceled by a shutdown of the runner
+func shutDown(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errShutdown)
+}
+
+type queuedExecution struct {
+	platform  string
+	execution shared_models.Executions
+	alertID   string
+	resume    bool
+	priority  int
+	queuedAt  time.Time
+	startedAt time.Time
+}
+
+// ExecutionStatus describes a queued or running execution of the pool
+type ExecutionStatus struct {
+	ID        string     `json:"id"`
+	FlowID    string     `json:"flow_id"`
+	Platform  string     `json:"platform"`
+	Priority  int        `json:"priority"`
+	QueuedAt  time.Time  `json:"queued_at"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
+// PoolStatus lists the running executions and the queue in the order it is processed
+type PoolStatus struct {
+	Slots   int               `json:"slots"`
+	Running []ExecutionStatus `json:"running"`
+	Queue   []ExecutionStatus `json:"queue"`
+	// Outbox is the number of updates waiting for their platform to be reachable again
+	Outbox int `json:"outbox"`
+}
+
+// Pool processes executions on a bounded number of workers. The pollers feed
+// executions into its queue and stop claiming new work while all slots are taken.
+// Queued executions are started by priority and in arrival order within a priority.
+type Pool struct {
+	cfg           config.Config
+	actions       []shared_models.Action
+	loadedPlugins map[string]plugins.Plugin
+	size          int
+	// workers is the number of running workers, it exceeds size until lent slots are returned
+	workers int
+
+	// ctx is the parent of all execution contexts and canceled on shutdown
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	// stopped is canceled once the pool is closed, e.g. to end the event streams
+	stopped context.Context
+	stop    context.CancelFunc
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []queuedExecution
+	inFlight map[string]bool
+	running  map[string]queuedExecution
+	closed   bool
+}
+
+// NewPool creates a pool with max_concurrent_executions workers and starts them
+func NewPool(cfg config.Config, actions []shared_models.Action, loadedPlugins map[string]plugins.Plugin) *Pool {
+	size := cfg.MaxConcurrentExecutions
+	if size < 1 {
+		size = 1
+	}
+
+	p := &Pool{
+		cfg:           cfg,
+		actions:       actions,
+		loadedPlugins: loadedPlugins,
+		size:          size,
+		workers:       size,
+		inFlight:      make(map[string]bool),
+		running:       make(map[string]queuedExecution),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	// executions reach their pool through their context, e.g. to lend their slot
+	p.ctx, p.cancel = context.WithCancelCause(context.WithValue(context.Background(), poolContextKey{}, p))
+	p.stopped, p.stop = context.WithCancel(context.Background())
+
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+
+	log.Infof("Execution pool started with %d slots", size)
+
+	return p
+}
+
+// Free returns the number of executions the pool can accept right now
+func (p *Pool) Free() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size - len(p.running) - len(p.queue)
+}
+
+// enqueue adds a batch of pending executions to the queue by priority. Executions
+// which are already queued or running are ignored. If there are not enough free
+// slots the executions with the lowest priority are left to a later poll and false is returned.
+func (p *Pool) enqueue(batch []queuedExecution) bool {
+	p.mu.Lock()
+	var pending []queuedExecution
+	for _, item := range batch {
+		if !p.inFlight[item.execution.ID.String()] {
+			pending = append(pending, item)
+		}
+	}
+	p.mu.Unlock()
+
+	for i := range pending {
+		pending[i].priority = executionPriority(p.cfg, pending[i].platform, pending[i].execution, pending[i].alertID)
+		pending[i].queuedAt = time.Now()
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].priority > pending[j].priority
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	queued := true
+	for _, item := range pending {
+		id := item.execution.ID.String()
+		if p.closed || p.inFlight[id] {
+			continue
+		}
+		if p.size-len(p.running)-len(p.queue) <= 0 {
+			log.Debugf("Execution %s not queued, no free slot", id)
+			queued = false
+			continue
+		}
+
+		p.inFlight[id] = true
+		p.insert(item)
+	}
+	p.cond.Broadcast()
+
+	return queued
+}
+
+// insert adds an execution behind all queued executions with the same or a higher priority
+func (p *Pool) insert(item queuedExecution) {
+	i := sort.Search(len(p.queue), func(i int) bool {
+		return p.queue[i].priority < item.priority
+	})
+	p.queue = append(p.queue, queuedExecution{})
+	copy(p.queue[i+1:], p.queue[i:])
+	p.queue[i] = item
+}
+
+// Status returns the running executions and the queue in the order it is processed
+func (p *Pool) Status() PoolStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := PoolStatus{
+		Slots:   p.size,
+		Running: []ExecutionStatus{},
+		Queue:   []ExecutionStatus{},
+		Outbox:  outbox.Len(),
+	}
+	for _, item := range p.running {
+		status.Running = append(status.Running, item.status())
+	}
+	sort.Slice(status.Running, func(i, j int) bool {
+		return status.Running[i].StartedAt.Before(*status.Running[j].StartedAt)
+	})
+	for _, item := range p.queue {
+		status.Queue = append(status.Queue, item.status())
+	}
+
+	return status
+}
+
+func (q queuedExecution) status() ExecutionStatus {
+	status := ExecutionStatus{
+		ID:       q.execution.ID.String(),
+		FlowID:   q.execution.FlowID,
+		Platform: q.platform,
+		Priority: q.priority,
+		QueuedAt: q.queuedAt,
+	}
+	if !q.startedAt.IsZero() {
+		startedAt := q.startedAt
+		status.StartedAt = &startedAt
+	}
+	return status
+}
+
+// Resume queues an execution interrupted by a restart of the runner. Recovered
+// executions are queued even if all slots are taken.
+func (p *Pool) Resume(platform string, execution shared_models.Executions, alertID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := execution.ID.String()
+	if p.closed || p.inFlight[id] {
+		return false
+	}
+
+	p.inFlight[id] = true
+	p.insert(queuedExecution{
+		platform:  platform,
+		execution: execution,
+		alertID:   alertID,
+		resume:    true,
+		queuedAt:  time.Now(),
+	})
+	p.cond.Broadcast()
+
+	return true
+}
+
+// Close stops the pool from accepting executions. Queued executions which have not
+// started yet are dropped, they are still pending on the platform and picked up again.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	p.stop()
+	for _, item := range p.queue {
+		delete(p.inFlight, item.execution.ID.String())
+	}
+	p.queue = nil
+}
+
+// Closed reports whether the pool stopped accepting executions
+func (p *Pool) Closed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// Wait waits up to timeout for all running executions to end. It returns false
+// if executions are still running afterwards.
+func (p *Pool) Wait(timeout time.Duration) bool {
+	idle := make(chan struct{})
+	go func() {
+		p.mu.Lock()
+		for len(p.running) > 0 || len(p.queue) > 0 {
+			p.cond.Wait()
+		}
+		p.mu.Unlock()
+		close(idle)
+	}()
+
+	select {
+	case <-idle:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// CancelRunning cancels all running executions. Their running steps and the
+// executions themselves end as canceled.
+func (p *Pool) CancelRunning() {
+	p.cancel(errShutdown)
+}
+
+// poolContextKey is the context key of the pool processing an execution
+type poolContextKey struct{}
+
+// poolFromContext returns the pool which processes the execution of ctx
+func poolFromContext(ctx context.Context) (*Pool, bool) {
+	p, ok := ctx.Value(poolContextKey{}).(*Pool)
+	return p, ok
+}
+
+// lend runs fn while the slot of the calling execution is lent to other executions,
+// e.g. to the sub flow it waits for. The pool runs an additional worker meanwhile.
+func (p *Pool) lend(fn func()) {
+	p.mu.Lock()
+	p.size++
+	p.workers++
+	p.mu.Unlock()
+	go p.work()
+
+	defer func() {
+		p.mu.Lock()
+		p.size--
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}()
+
+	fn()
+}
+
+func (p *Pool) work() {
+	for {
+		p.mu.Lock()
+		for (len(p.queue) == 0 || p.closed) && p.workers <= p.size {
+			p.cond.Wait()
+		}
+		// a worker stops once a lent slot was returned
+		if p.workers > p.size {
+			p.workers--
+			p.mu.Unlock()
+			return
+		}
+		item := p.queue[0]
+		p.queue = p.queue[1:]
+		item.startedAt = time.Now()
+		p.running[item.execution.ID.String()] = item
+		p.mu.Unlock()
+
+		startProcessing(p.ctx, item.platform, p.cfg, p.actions, p.loadedPlugins, item.execution, item.alertID, item.resume)
+
+		p.mu.Lock()
+		delete(p.running, item.execution.ID.String())
+		delete(p.inFlight, item.execution.ID.String())
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}
+}
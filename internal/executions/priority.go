@@ -0,0 +1,67 @@
+package internal_executions
+
+import (
+	"encoding/json"
+	"strings"
+
+	af_models "github.com/v1Flows/alertFlow/services/backend/pkg/models"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/alerts"
+	"github.com/v1Flows/runner/pkg/flows"
+	"github.com/v1Flows/runner/pkg/models"
+	"github.com/v1Flows/runner/pkg/platform"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// executionPriority calculates the priority of a pending execution. It is the sum
+// of the priority of the alert severity and of all matching priority rules.
+// Without configured priorities every execution has the priority 0.
+func executionPriority(cfg config.Config, targetPlatform string, execution shared_models.Executions, alertID string) int {
+	if len(cfg.Priority.Severities) == 0 && len(cfg.Priority.Rules) == 0 {
+		return 0
+	}
+
+	var flow shared_models.Flows
+	if flowBytes, err := flows.GetFlowData(cfg, execution.FlowID, targetPlatform); err == nil {
+		var incoming models.IncomingSharedFlow
+		if err := json.Unmarshal(flowBytes, &incoming); err == nil {
+			flow = incoming.FlowData
+		}
+	}
+
+	var alert af_models.Alerts
+	if platform.Alerts(cfg, targetPlatform) && alertID != "" {
+		if data, err := alerts.GetAlert(cfg, targetPlatform, alertID); err == nil {
+			alert = data
+		}
+	}
+
+	data := newExecutionOutputs().templateData(flow, alert, execution)
+	priority := 0
+
+	if len(cfg.Priority.Severities) > 0 {
+		// alerts without the severity field have no severity priority
+		if severity, err := renderTemplate("severity_field", cfg.Priority.SeverityField, data); err == nil {
+			priority += cfg.Priority.Severities[strings.ToLower(severity)]
+		}
+	}
+
+	for _, rule := range cfg.Priority.Rules {
+		if rule.Flow != "" && rule.Flow != flow.ID.String() && rule.Flow != flow.Name {
+			continue
+		}
+
+		match, err := evaluateExpression("when", rule.When, configTemplateDelim, data)
+		if err != nil {
+			log.Debugf("Priority rule %q does not match execution %s: %v", rule.When, execution.ID, err)
+			continue
+		}
+		if match {
+			priority += rule.Priority
+		}
+	}
+
+	return priority
+}
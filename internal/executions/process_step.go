@@ -1,9 +1,13 @@
 package internal_executions
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	af_models "github.com/v1Flows/alertFlow/services/backend/pkg/models"
 	"github.com/v1Flows/runner/config"
 	"github.com/v1Flows/runner/internal/common"
@@ -17,7 +21,9 @@ import (
 
 func RegisterActions(loadedPluginActions []shared_models.Plugin) (actions []shared_models.Action) {
 	for _, plugin := range loadedPluginActions {
-		actions = append(actions, plugin.Action)
+		action := plugin.Action
+		action.Params = append(append([]shared_models.Params{}, action.Params...), runnerParams...)
+		actions = append(actions, action)
 	}
 
 	if len(actions) == 0 {
@@ -27,13 +33,28 @@ func RegisterActions(loadedPluginActions []shared_models.Plugin) (actions []shar
 	return actions
 }
 
-func processStep(cfg config.Config, workspace string, actions []shared_models.Action, loadedPlugins map[string]plugins.Plugin, flow shared_models.Flows, flowBytes []byte, alert af_models.Alerts, steps []shared_models.ExecutionSteps, step shared_models.ExecutionSteps, execution shared_models.Executions) (res plugins.Response, success bool, err error) {
+func processStep(ctx context.Context, cfg config.Config, workspace string, actions []shared_models.Action, loadedPlugins map[string]plugins.Plugin, flow shared_models.Flows, flowBytes []byte, alert af_models.Alerts, outputs *executionOutputs, steps []shared_models.ExecutionSteps, step shared_models.ExecutionSteps, execution shared_models.Executions) (res plugins.Response, success bool, err error) {
 	targetPlatform, ok := platform.GetPlatformForExecution(execution.ID.String())
 	if !ok {
 		log.Error("Failed to get platform")
 		return
 	}
 
+	// loops evaluate the condition and everything else once per item
+	if actionParam(step.Action, paramForEach) != "" && !outputs.inLoop {
+		return processLoopStep(ctx, cfg, workspace, actions, loadedPlugins, flow, flowBytes, alert, outputs, steps, step, execution, targetPlatform)
+	}
+
+	data := outputs.templateData(flow, alert, execution)
+
+	run, err := evaluateCondition(step.Action, data)
+	if err != nil {
+		return failStep(cfg, execution, step, targetPlatform, "Evaluation of the when condition failed", err)
+	}
+	if !run {
+		return skipStep(cfg, execution, step, targetPlatform, "Condition evaluated to false", "When: "+actionParam(step.Action, paramWhen))
+	}
+
 	step.Status = "running"
 	step.StartedAt = time.Now()
 	step.RunnerID = execution.RunnerID
@@ -110,8 +131,44 @@ func processStep(cfg config.Config, workspace string, actions []shared_models.Ac
 		return plugins.Response{}, false, errors.New("plugin not found")
 	}
 
+	step.Action, err = renderActionParams(step.Action, data)
+	if err != nil {
+		return failStep(cfg, execution, step, targetPlatform, "Templating of action params failed", err)
+	}
+
+	// actions with the same concurrency key never run at the same time
+	if key := actionParam(step.Action, paramConcurrencyKey); key != "" {
+		acquired, err := concurrency.acquire(ctx, key, execution, actionParam(step.Action, paramConcurrencyPolicy))
+		if errors.Is(err, errHeldByParent) {
+			return failStep(cfg, execution, step, targetPlatform, "Concurrency", err)
+		}
+		if err != nil {
+			return interruptStep(ctx, cfg, execution, step, targetPlatform, 0, err)
+		}
+		if !acquired {
+			return skipStep(cfg, execution, step, targetPlatform, "Concurrency key "+key+" is held by another execution")
+		}
+		defer concurrency.release(key, execution.ID.String())
+	}
+
+	// a dry run records the resolved params and asks the plugin for no side effects.
+	// Pipeline steps have no action ID, they run as usual to load the flow and alert.
+	isDryRun := step.Action.ID != uuid.Nil && dryRun(cfg, flow)
+	if isDryRun {
+		// plugins which do not declare dry run support might make side effects anyway
+		supported := plugins.SupportsDryRun(loadedPlugins[step.Action.Plugin])
+		step.Messages = append(step.Messages, dryRunMessage(step.Action, supported))
+		if !supported {
+			return skipStep(cfg, execution, step, targetPlatform, "Plugin "+step.Action.Plugin+" does not support dry runs and was not called")
+		}
+		if err := executions.UpdateStep(cfg, execution.ID.String(), step, targetPlatform); err != nil {
+			log.Error(err)
+			return plugins.Response{}, false, err
+		}
+	}
+
 	req := plugins.ExecuteTaskRequest{
-		Config:    cfg,
+		Config:    cfg.ForPlatform(targetPlatform),
 		Flow:      flow,
 		FlowBytes: flowBytes,
 		Execution: execution,
@@ -119,9 +176,27 @@ func processStep(cfg config.Config, workspace string, actions []shared_models.Ac
 		Alert:     alert,
 		Platform:  targetPlatform,
 		Workspace: workspace,
+		DryRun:    isDryRun,
+	}
+	if isDryRun {
+		req.Args = map[string]string{"dry_run": "true"}
 	}
 
-	res, err = loadedPlugins[step.Action.Plugin].ExecuteTask(req)
+	timeout, err := stepTimeout(cfg, step.Action)
+	if err != nil {
+		return failStep(cfg, execution, step, targetPlatform, "Invalid action settings", fmt.Errorf("invalid timeout: %w", err))
+	}
+
+	policy, err := actionRetryPolicy(step.Action)
+	if err != nil {
+		return failStep(cfg, execution, step, targetPlatform, "Invalid action settings", err)
+	}
+
+	result := executeWithRetry(ctx, cfg, loadedPlugins[step.Action.Plugin], req, timeout, policy, targetPlatform)
+	step, res, err = result.step, result.res, result.err
+	if result.interrupted != nil {
+		return interruptStep(ctx, cfg, execution, step, targetPlatform, timeout, result.interrupted)
+	}
 	if err != nil {
 		log.Error(err)
 
@@ -153,6 +228,10 @@ func processStep(cfg config.Config, workspace string, actions []shared_models.Ac
 		return plugins.Response{}, false, err
 	}
 
+	if res.Data["status"] == "interactionWaiting" {
+		return awaitInteraction(ctx, cfg, execution, step, targetPlatform, res)
+	}
+
 	if res.Success {
 		return res, true, nil
 	} else {
@@ -161,3 +240,127 @@ func processStep(cfg config.Config, workspace string, actions []shared_models.Ac
 
 	// return data, true, false, false, false, nil
 }
+
+// skipStep marks the step as skipped, e.g. because its when condition is false
+func skipStep(cfg config.Config, execution shared_models.Executions, step shared_models.ExecutionSteps, targetPlatform string, reasons ...string) (plugins.Response, bool, error) {
+	log.Debugf("Skip step %s: %s", step.ID, strings.Join(reasons, ", "))
+
+	message := shared_models.Message{
+		Title: "Skipped",
+	}
+	for _, reason := range reasons {
+		message.Lines = append(message.Lines, shared_models.Line{
+			Content: reason,
+		})
+	}
+	step.Messages = append(step.Messages, message)
+	step.Status = "skipped"
+	step.RunnerID = execution.RunnerID
+	step.StartedAt = time.Now()
+	step.FinishedAt = time.Now()
+
+	if err := executions.UpdateStep(cfg, execution.ID.String(), step, targetPlatform); err != nil {
+		log.Error(err)
+		return plugins.Response{}, false, err
+	}
+
+	return plugins.Response{Data: map[string]interface{}{"status": "skipped"}, Success: true}, true, nil
+}
+
+// failStep fails the step before its plugin is called
+func failStep(cfg config.Config, execution shared_models.Executions, step shared_models.ExecutionSteps, targetPlatform string, reason string, err error) (plugins.Response, bool, error) {
+	log.Warnf("%s for step %s: %v", reason, step.ID, err)
+
+	step.Messages = append(step.Messages, shared_models.Message{
+		Title: "Error",
+		Lines: []shared_models.Line{
+			{
+				Content: reason,
+				Color:   "danger",
+			},
+			{
+				Content: "Error: " + err.Error(),
+				Color:   "danger",
+			},
+			{
+				Content: "Cancel execution",
+				Color:   "danger",
+			},
+		},
+	})
+	step.Status = "error"
+	step.FinishedAt = time.Now()
+
+	if err := executions.UpdateStep(cfg, execution.ID.String(), step, targetPlatform); err != nil {
+		log.Error(err)
+		return plugins.Response{}, false, err
+	}
+
+	return plugins.Response{}, false, err
+}
+
+// interruptStep finishes a step whose plugin call was interrupted by a timeout or a cancellation
+func interruptStep(ctx context.Context, cfg config.Config, execution shared_models.Executions, step shared_models.ExecutionSteps, targetPlatform string, timeout time.Duration, interrupted error) (plugins.Response, bool, error) {
+	log.Warnf("Step %s interrupted: %v", step.ID, interrupted)
+
+	if errors.Is(interrupted, context.DeadlineExceeded) {
+		reason := fmt.Sprintf("Step exceeded its timeout of %s", timeout)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			reason = "Execution exceeded its timeout"
+		}
+
+		step.Messages = append(step.Messages, shared_models.Message{
+			Title: "Timeout",
+			Lines: []shared_models.Line{
+				{
+					Content: reason,
+					Color:   "danger",
+				},
+				{
+					Content: "Cancel execution",
+					Color:   "danger",
+				},
+			},
+		})
+		step.Status = "timeout"
+	} else {
+		reason := "Canceled by runner due to the failure of another step"
+		if canceledRemotely(ctx) {
+			reason = "Canceled by runner because the execution was canceled"
+		} else if shutDown(ctx) {
+			reason = "Canceled by runner because it was shut down"
+		} else if superseded(ctx) {
+			reason = "Canceled by runner because a newer execution of the concurrency group started"
+		}
+
+		step.Messages = append(step.Messages, shared_models.Message{
+			Title: "Canceled",
+			Lines: []shared_models.Line{
+				{
+					Content: reason,
+					Color:   "danger",
+				},
+			},
+		})
+		step.Status = "canceled"
+		step.CanceledBy = "Runner"
+		step.CanceledAt = time.Now()
+	}
+	step.FinishedAt = time.Now()
+
+	if err := executions.UpdateStep(cfg, execution.ID.String(), step, targetPlatform); err != nil {
+		log.Error(err)
+	}
+
+	return plugins.Response{}, false, interrupted
+}
+
+// stepTimeout returns the timeout param of the action or the configured default step timeout
+func stepTimeout(cfg config.Config, action shared_models.Action) (time.Duration, error) {
+	value := actionParam(action, paramTimeout)
+	if value == "" {
+		return cfg.StepTimeout, nil
+	}
+
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,155 @@
+package internal_executions
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/platform"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxPushReconnectBackoff caps the delay between two attempts to connect an event stream
+const maxPushReconnectBackoff = time.Minute
+
+// pushed wakes the goroutines which check an execution on the platform once an event for it was pushed
+var pushed = newNotifier()
+
+type notifier struct {
+	mu      sync.Mutex
+	waiters map[string]map[chan struct{}]struct{}
+}
+
+func newNotifier() *notifier {
+	return &notifier{waiters: make(map[string]map[chan struct{}]struct{})}
+}
+
+// subscribe returns a channel which is signaled for every event of the execution
+// and a function which unsubscribes it again
+func (n *notifier) subscribe(executionID string) (<-chan struct{}, func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if n.waiters[executionID] == nil {
+		n.waiters[executionID] = make(map[chan struct{}]struct{})
+	}
+	n.waiters[executionID][ch] = struct{}{}
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		delete(n.waiters[executionID], ch)
+		if len(n.waiters[executionID]) == 0 {
+			delete(n.waiters, executionID)
+		}
+	}
+}
+
+// notify signals all subscribers of the execution, a subscriber which was not
+// done with the last signal gets only one
+func (n *notifier) notify(executionID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for ch := range n.waiters[executionID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// pushDelivery receives the events of a connection. While its stream is connected
+// pending executions are only polled every push.poll_interval.
+type pushDelivery struct {
+	platform  string
+	connected atomic.Bool
+	// poll asks the poller to poll right away
+	poll chan struct{}
+}
+
+func newPushDelivery(targetPlatform string) *pushDelivery {
+	return &pushDelivery{
+		platform: targetPlatform,
+		poll:     make(chan struct{}, 1),
+	}
+}
+
+// requestPoll lets the poller poll on its next turn
+func (d *pushDelivery) requestPoll() {
+	select {
+	case d.poll <- struct{}{}:
+	default:
+	}
+}
+
+// run keeps the event stream of the platform connected until the pool is closed.
+// A lost or idle stream is reconnected with doubling backoff, meanwhile executions are polled.
+func (d *pushDelivery) run(cfg config.Config, driver platform.Platform, runnerID string, pool *Pool) {
+	backoff := cfg.Push.ReconnectBackoff
+	for {
+		// the stream is closed once the runner shuts down
+		err := driver.Subscribe(pool.stopped, cfg, runnerID, func(event platform.Event) {
+			d.handle(event, pool)
+		})
+		if pool.stopped.Err() != nil {
+			d.connected.Store(false)
+			log.Infof("Event stream of %s closed", d.platform)
+			return
+		}
+
+		if d.connected.Swap(false) {
+			// events might have been missed until the stream is connected again
+			log.Warnf("Event stream of %s closed, falling back to polling: %v", d.platform, err)
+			backoff = cfg.Push.ReconnectBackoff
+			d.requestPoll()
+		} else {
+			log.Warnf("Failed to connect event stream of %s, retry in %s: %v", d.platform, backoff, err)
+		}
+
+		select {
+		case <-pool.stopped.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxPushReconnectBackoff {
+			backoff = maxPushReconnectBackoff
+		}
+	}
+}
+
+func (d *pushDelivery) handle(event platform.Event, pool *Pool) {
+	switch event.Type {
+	case platform.EventConnected:
+		log.Infof("Event stream of %s connected", d.platform)
+		d.connected.Store(true)
+		// executions which were created while the stream was not connected
+		d.requestPoll()
+	case platform.EventExecution:
+		if event.Execution == nil {
+			d.requestPoll()
+			return
+		}
+
+		log.Debugf("Execution %s pushed by %s", event.ExecutionID, d.platform)
+		platform.SetPlatformForExecution(event.ExecutionID, d.platform)
+		// executions without a free slot are picked up by a later poll
+		if !pool.enqueue([]queuedExecution{{
+			platform:  d.platform,
+			execution: *event.Execution,
+			alertID:   event.AlertID,
+		}}) {
+			d.requestPoll()
+		}
+	case platform.EventCancel, platform.EventInteraction, platform.EventStatus:
+		log.Debugf("%s event for execution %s pushed by %s", event.Type, event.ExecutionID, d.platform)
+		pushed.notify(event.ExecutionID)
+	default:
+		log.Debugf("Ignoring unknown %s event from %s", event.Type, d.platform)
+	}
+}
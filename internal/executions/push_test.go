@@ -0,0 +1,183 @@
+package internal_executions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/platform"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// standInPlatform serves the event stream and the pending executions of runner r1.
+// The first stream sends the events and stays connected until disconnect is closed,
+// later streams are refused.
+type standInPlatform struct {
+	events     []string
+	polls      atomic.Int32
+	streams    atomic.Int32
+	sent       chan struct{}
+	disconnect chan struct{}
+}
+
+func (s *standInPlatform) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/v1/runners/r1/executions/pending":
+		s.polls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"executions":[]}`)
+	case "/api/v1/runners/r1/events":
+		if s.streams.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": connected\n\n")
+		for _, event := range s.events {
+			fmt.Fprint(w, event)
+		}
+		w.(http.Flusher).Flush()
+		close(s.sent)
+
+		select {
+		case <-s.disconnect:
+		case <-r.Context().Done():
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newTestPool returns a pool whose workers are not started, executions stay in its queue
+func newTestPool(cfg config.Config, size int) *Pool {
+	p := &Pool{
+		cfg:      cfg,
+		size:     size,
+		workers:  size,
+		inFlight: make(map[string]bool),
+		running:  make(map[string]queuedExecution),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	p.ctx, p.cancel = context.WithCancelCause(context.WithValue(context.Background(), poolContextKey{}, p))
+	p.stopped, p.stop = context.WithCancel(context.Background())
+	return p
+}
+
+func sseEvent(t *testing.T, name string, event platform.Event) string {
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("event: %s\ndata: %s\n\n", name, data)
+}
+
+// waitFor polls cond until it is true or fails the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPushDelivery(t *testing.T) {
+	previous := pollInterval
+	pollInterval = 20 * time.Millisecond
+	defer func() { pollInterval = previous }()
+
+	pushedID := uuid.New()
+	canceledID := uuid.New()
+
+	standIn := &standInPlatform{
+		sent:       make(chan struct{}),
+		disconnect: make(chan struct{}),
+		events: []string{
+			sseEvent(t, platform.EventExecution, platform.Event{
+				Execution: &shared_models.Executions{ID: pushedID, FlowID: "flow"},
+				AlertID:   "alert",
+			}),
+			sseEvent(t, platform.EventCancel, platform.Event{ExecutionID: canceledID.String()}),
+		},
+	}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	cfg := config.Config{
+		Platforms: []config.PlatformConfig{{Name: "af", Type: "alertflow", URL: srv.URL, APIKey: "key"}},
+		API:       config.APIConfig{Timeout: time.Second},
+		Push: config.PushConfig{
+			Enabled:          true,
+			PollInterval:     time.Hour,
+			ReconnectBackoff: time.Hour,
+			IdleTimeout:      time.Minute,
+		},
+	}
+	driver, err := platform.Get(cfg, "af")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canceled, unsubscribe := pushed.subscribe(canceledID.String())
+	defer unsubscribe()
+
+	pool := newTestPool(cfg, 2)
+	done := make(chan struct{})
+	go func() {
+		pollExecutions(cfg, driver, "r1", pool)
+		close(done)
+	}()
+	defer func() {
+		pool.Close()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Error("poller did not stop once the pool was closed")
+		}
+	}()
+
+	select {
+	case <-standIn.sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream was not connected")
+	}
+
+	// a pushed execution is queued without a poll
+	waitFor(t, "the pushed execution to be queued", func() bool {
+		status := pool.Status()
+		return len(status.Queue) == 1 && status.Queue[0].ID == pushedID.String() && status.Queue[0].Platform == "af"
+	})
+	if p, ok := platform.GetPlatformForExecution(pushedID.String()); !ok || p != "af" {
+		t.Errorf("pushed execution is routed to %q", p)
+	}
+
+	// a pushed cancel wakes the watchers of the execution
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel event did not notify the watchers of the execution")
+	}
+
+	// once connected the poller catches up a single time and then waits for push.poll_interval
+	waitFor(t, "the catch-up poll", func() bool { return standIn.polls.Load() > 0 })
+	time.Sleep(50 * time.Millisecond)
+	connectedPolls := standIn.polls.Load()
+	time.Sleep(10 * pollInterval)
+	if polls := standIn.polls.Load(); polls != connectedPolls {
+		t.Errorf("polled %d times while the stream was connected", polls-connectedPolls)
+	}
+
+	// a lost stream falls back to polling on every tick
+	close(standIn.disconnect)
+	waitFor(t, "polling to take over", func() bool { return standIn.polls.Load() >= connectedPolls+3 })
+}
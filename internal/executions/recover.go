@@ -0,0 +1,164 @@
+package internal_executions
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/internal/journal"
+	"github.com/v1Flows/runner/pkg/executions"
+	"github.com/v1Flows/runner/pkg/platform"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RecoverExecutions reconciles the journal after a restart. Executions which are
+// still running on the platform are resumed, failed or canceled according to the
+// journal recovery policy.
+func RecoverExecutions(cfg config.Config, pool *Pool) {
+	records, err := journal.Interrupted()
+	if err != nil {
+		log.Errorf("Failed to read journal: %v", err)
+		return
+	}
+
+	configManager := config.GetInstance()
+	for _, record := range records {
+		execution := record.Execution
+		executionID := execution.ID.String()
+
+		if _, ok := cfg.Platform(record.Platform); !ok {
+			log.Warnf("Journal of execution %s belongs to the platform %s which is no longer configured, discarding it", executionID, record.Platform)
+			journal.Finish(executionID)
+			continue
+		}
+		if execution.RunnerID != configManager.GetRunnerID(record.Platform) {
+			log.Warnf("Journal of execution %s belongs to another runner, discarding it", executionID)
+			journal.Finish(executionID)
+			continue
+		}
+
+		platform.SetPlatformForExecution(executionID, record.Platform)
+
+		// the execution might have been ended on the platform while the runner was down
+		if current, err := executions.GetExecution(cfg, executionID, record.Platform); err == nil {
+			switch current.Status {
+			case "pending", "running", "paused", "interactionWaiting":
+			default:
+				log.Infof("Execution %s already ended with status %s", executionID, current.Status)
+				journal.Finish(executionID)
+				continue
+			}
+		}
+
+		switch cfg.Journal.RecoveryPolicy {
+		case "resume":
+			log.Infof("Resuming interrupted execution %s", executionID)
+			pool.Resume(record.Platform, execution, record.AlertID)
+		case "cancel":
+			log.Infof("Canceling interrupted execution %s", executionID)
+			endInterrupted(cfg, execution, record.Platform, "canceled")
+		default:
+			log.Infof("Failing interrupted execution %s", executionID)
+			endInterrupted(cfg, execution, record.Platform, "error")
+		}
+	}
+}
+
+// endInterrupted ends the steps which were running when the runner stopped and the execution itself
+func endInterrupted(cfg config.Config, execution shared_models.Executions, targetPlatform string, status string) {
+	executionID := execution.ID.String()
+
+	steps, err := executions.GetSteps(cfg, executionID, targetPlatform)
+	if err != nil {
+		log.Error(err)
+	}
+
+	for _, step := range steps {
+		switch step.Status {
+		case "running", "paused", "interactionWaiting":
+			endInterruptedStep(cfg, executionID, step, targetPlatform, status)
+		}
+	}
+
+	cancelRemainingSteps(cfg, executionID)
+
+	// the execution was never started by this process, so it is ended without
+	// executions.End, which would release a busy mark it never set
+	execution.Status = status
+	execution.FinishedAt = time.Now()
+	if err := executions.UpdateExecution(cfg, execution, targetPlatform); err != nil {
+		log.Error(err)
+	}
+
+	journal.Finish(executionID)
+
+	err = os.RemoveAll(fmt.Sprintf("%s/%s", cfg.WorkspaceDir, execution.ID))
+	if err != nil {
+		log.Error("Error deleting workspace dir: ", err)
+	}
+}
+
+// endInterruptedStep ends a step which was not finished when the runner stopped
+func endInterruptedStep(cfg config.Config, executionID string, step shared_models.ExecutionSteps, targetPlatform string, status string) {
+	step.Status = status
+	if status == "canceled" {
+		step.CanceledBy = "Runner"
+		step.CanceledAt = time.Now()
+	}
+	step.Messages = append(step.Messages, shared_models.Message{
+		Title: "Interrupted",
+		Lines: []shared_models.Line{
+			{
+				Content: "Interrupted by a restart of the runner",
+				Color:   "danger",
+			},
+		},
+	})
+	step.FinishedAt = time.Now()
+
+	if err := executions.UpdateStep(cfg, executionID, step, targetPlatform); err != nil {
+		log.Error(err)
+	}
+}
+
+// resumableSteps returns the flow action steps a resumed execution created before
+// the restart, keyed by action ID, and the journaled results of those which succeeded
+// or were skipped. Unfinished initial steps and loop items are canceled, the initial
+// steps are sent again and the loop items are recreated by their parent.
+func resumableSteps(cfg config.Config, execution shared_models.Executions, targetPlatform string) (map[string]shared_models.ExecutionSteps, map[string]journal.StepRecord) {
+	existing := make(map[string]shared_models.ExecutionSteps)
+	finished := make(map[string]journal.StepRecord)
+
+	record, err := journal.Load(execution.ID.String())
+	if err != nil {
+		log.Warnf("Failed to load journal of execution %s: %v", execution.ID, err)
+	}
+
+	steps, err := executions.GetSteps(cfg, execution.ID.String(), targetPlatform)
+	if err != nil {
+		log.Error(err)
+		return existing, finished
+	}
+
+	for _, step := range steps {
+		// initial steps have no action ID and loop items are recreated by their parent
+		if step.ParentID != "" || step.Action.ID == uuid.Nil {
+			switch step.Status {
+			case "pending", "running", "paused", "interactionWaiting":
+				endInterruptedStep(cfg, execution.ID.String(), step, targetPlatform, "canceled")
+			}
+			continue
+		}
+		existing[step.Action.ID.String()] = step
+
+		if result, ok := record.Steps[step.ID.String()]; ok && (result.Status == "success" || result.Status == "skipped") {
+			finished[step.ID.String()] = result
+		}
+	}
+
+	return existing, finished
+}
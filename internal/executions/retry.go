@@ -0,0 +1,176 @@
+package internal_executions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/executions"
+	"github.com/v1Flows/runner/pkg/plugins"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// retryPolicy describes how often and when a failed action is executed again
+type retryPolicy struct {
+	maxAttempts   int
+	initialDelay  time.Duration
+	backoffFactor float64
+	retryOn       map[string]bool
+}
+
+// attemptResult is the outcome of the last plugin call of a step
+type attemptResult struct {
+	step        shared_models.ExecutionSteps
+	res         plugins.Response
+	err         error
+	interrupted error
+}
+
+// actionRetryPolicy reads the retry params of the action
+func actionRetryPolicy(action shared_models.Action) (retryPolicy, error) {
+	policy := retryPolicy{
+		maxAttempts:   1,
+		initialDelay:  5 * time.Second,
+		backoffFactor: 2,
+		// a timed out plugin call is only abandoned, the plugin might still be running
+		retryOn: map[string]bool{"error": true, "failure": true},
+	}
+
+	if value := actionParam(action, paramRetryMaxAttempts); value != "" {
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 1 {
+			return policy, fmt.Errorf("%s must be a number greater than 0", paramRetryMaxAttempts)
+		}
+		policy.maxAttempts = attempts
+	}
+
+	if value := actionParam(action, paramRetryInitialDelay); value != "" {
+		delay, err := time.ParseDuration(value)
+		if err != nil || delay < 0 {
+			return policy, fmt.Errorf("%s must be a duration like 5s", paramRetryInitialDelay)
+		}
+		policy.initialDelay = delay
+	}
+
+	if value := actionParam(action, paramRetryBackoffFactor); value != "" {
+		factor, err := strconv.ParseFloat(value, 64)
+		if err != nil || factor < 1 {
+			return policy, fmt.Errorf("%s must be a number of at least 1", paramRetryBackoffFactor)
+		}
+		policy.backoffFactor = factor
+	}
+
+	if outcomes := actionParamList(action, paramRetryOn); len(outcomes) > 0 {
+		policy.retryOn = make(map[string]bool)
+		for _, outcome := range outcomes {
+			switch outcome {
+			case "error", "failure", "timeout":
+				policy.retryOn[outcome] = true
+			default:
+				return policy, fmt.Errorf("%s contains unknown outcome %s", paramRetryOn, outcome)
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+// attemptOutcome classifies a single plugin call for the retry policy
+func attemptOutcome(res plugins.Response, err error, interrupted error) string {
+	switch {
+	case errors.Is(interrupted, context.DeadlineExceeded):
+		return "timeout"
+	case interrupted != nil:
+		return "canceled"
+	case err != nil:
+		return "error"
+	case res.Data["status"] == "noPatternMatch" || res.Data["status"] == "canceled":
+		return res.Data["status"].(string)
+	case !res.Success:
+		return "failure"
+	default:
+		return "success"
+	}
+}
+
+// executeWithRetry calls the plugin until an attempt succeeds, its outcome is not
+// retryable or all attempts are used up. With more than one attempt every attempt
+// is reported as its own message block on the step.
+func executeWithRetry(ctx context.Context, cfg config.Config, plugin plugins.Plugin, req plugins.ExecuteTaskRequest, timeout time.Duration, policy retryPolicy, targetPlatform string) attemptResult {
+	executionID := req.Execution.ID.String()
+	delay := policy.initialDelay
+
+	for attempt := 1; ; attempt++ {
+		stepCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			stepCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+
+		res, err := plugins.ExecuteTaskWithContext(stepCtx, plugin, req)
+		result := attemptResult{step: req.Step, res: res, err: err, interrupted: stepCtx.Err()}
+		cancel()
+
+		if policy.maxAttempts <= 1 {
+			return result
+		}
+
+		outcome := attemptOutcome(res, err, result.interrupted)
+		retry := outcome != "success" && attempt < policy.maxAttempts && policy.retryOn[outcome] && ctx.Err() == nil
+
+		// the plugin reports its messages on its own, continue with the latest version of the step
+		if latest, err := executions.GetStep(cfg, executionID, req.Step.ID.String(), targetPlatform); err == nil {
+			result.step = latest
+		} else {
+			log.Warnf("Failed to refresh step %s: %v", req.Step.ID, err)
+		}
+
+		message := shared_models.Message{
+			Title: fmt.Sprintf("Attempt %d of %d", attempt, policy.maxAttempts),
+		}
+		switch {
+		case outcome == "success":
+			message.Lines = append(message.Lines, shared_models.Line{Content: "Succeeded", Color: "success"})
+		case err != nil:
+			message.Lines = append(message.Lines, shared_models.Line{Content: "Failed with " + outcome + ": " + err.Error(), Color: "danger"})
+		default:
+			message.Lines = append(message.Lines, shared_models.Line{Content: "Failed with " + outcome, Color: "danger"})
+		}
+		if retry {
+			message.Lines = append(message.Lines, shared_models.Line{Content: "Retry in " + delay.String(), Color: "warning"})
+			result.step.Status = "running"
+			result.step.FinishedAt = time.Time{}
+		}
+		result.step.Messages = append(result.step.Messages, message)
+
+		if !retry {
+			// errors and interruptions are persisted by processStep together with their message
+			if result.err == nil && result.interrupted == nil {
+				if err := executions.UpdateStep(cfg, executionID, result.step, targetPlatform); err != nil {
+					log.Error(err)
+				}
+			}
+			return result
+		}
+
+		if err := executions.UpdateStep(cfg, executionID, result.step, targetPlatform); err != nil {
+			log.Error(err)
+		}
+
+		log.Infof("Retry step %s in %s (attempt %d of %d)", req.Step.ID, delay, attempt+1, policy.maxAttempts)
+
+		select {
+		case <-ctx.Done():
+			result.interrupted = ctx.Err()
+			return result
+		case <-time.After(delay):
+		}
+
+		req.Step = result.step
+		delay = time.Duration(float64(delay) * policy.backoffFactor)
+	}
+}
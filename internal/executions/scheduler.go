@@ -0,0 +1,307 @@
+package internal_executions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/plugins"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// stepNode is a flow action step together with its position in the graph
+type stepNode struct {
+	step       shared_models.ExecutionSteps
+	dependsOn  []int
+	dependents []int
+}
+
+// stepResult is the outcome of a single processed step
+type stepResult struct {
+	index   int
+	res     plugins.Response
+	success bool
+	err     error
+	// soft is set for actions with continue_on_error
+	soft bool
+}
+
+// status maps the step result to the status used for scheduling and aggregation.
+// Errors and timeouts of actions with continue_on_error become a warning.
+func (r stepResult) status() string {
+	status := r.stepStatus()
+	if r.soft && (status == "error" || status == "timeout") {
+		return "warning"
+	}
+	return status
+}
+
+// stepStatus maps the step result to the status of the step itself
+func (r stepResult) stepStatus() string {
+	switch {
+	case errors.Is(r.err, context.Canceled):
+		return "canceled"
+	case errors.Is(r.err, context.DeadlineExceeded):
+		return "timeout"
+	case r.err != nil:
+		return "error"
+	case r.res.Data["status"] == "noPatternMatch":
+		return "noPatternMatch"
+	case r.res.Data["status"] == "canceled":
+		return "canceled"
+	case r.res.Data["status"] == "skipped":
+		return "skipped"
+	case !r.success:
+		return "error"
+	default:
+		return "success"
+	}
+}
+
+// buildStepGraph resolves the depends_on params of the flow action steps into a
+// directed graph. Actions without depends_on follow the closest preceding action
+// which does not depend on them when the flow is executed sequentially and start
+// right away when it is executed in parallel.
+func buildStepGraph(flow shared_models.Flows, steps []shared_models.ExecutionSteps) ([]*stepNode, error) {
+	nodes := make([]*stepNode, len(steps))
+	refs := make(map[string]int)
+	for i, step := range steps {
+		nodes[i] = &stepNode{step: step}
+
+		for _, ref := range []string{step.Action.ID.String(), step.Action.Name} {
+			if ref == "" {
+				continue
+			}
+			if other, ok := refs[ref]; ok && other != i {
+				// ambiguous references are marked and rejected once used
+				refs[ref] = -1
+				continue
+			}
+			refs[ref] = i
+		}
+	}
+
+	for i, node := range nodes {
+		for _, dependency := range actionParamList(node.step.Action, paramDependsOn) {
+			target, ok := refs[dependency]
+			if !ok {
+				return nil, fmt.Errorf("action %s depends on unknown action %s", node.step.Action.Name, dependency)
+			}
+			if target == -1 {
+				return nil, fmt.Errorf("action %s depends on %s which matches more than one action", node.step.Action.Name, dependency)
+			}
+			if target == i {
+				return nil, fmt.Errorf("action %s depends on itself", node.step.Action.Name)
+			}
+			node.dependsOn = append(node.dependsOn, target)
+		}
+	}
+
+	// an earlier action may depend on a later one, which then must not wait for it
+	if !flow.ExecParallel {
+		for i, node := range nodes {
+			if len(node.dependsOn) > 0 {
+				continue
+			}
+			for previous := i - 1; previous >= 0; previous-- {
+				if !dependsOn(nodes, previous, i) {
+					node.dependsOn = append(node.dependsOn, previous)
+					break
+				}
+			}
+		}
+	}
+
+	for i, node := range nodes {
+		for _, dependency := range node.dependsOn {
+			nodes[dependency].dependents = append(nodes[dependency].dependents, i)
+		}
+	}
+
+	if cycle := findCycle(nodes); len(cycle) > 0 {
+		return nil, fmt.Errorf("actions contain a dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	return nodes, nil
+}
+
+// dependsOn reports whether the node at from depends on target, directly or transitively
+func dependsOn(nodes []*stepNode, from int, target int) bool {
+	seen := make(map[int]bool)
+	pending := []int{from}
+	for len(pending) > 0 {
+		i := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, dependency := range nodes[i].dependsOn {
+			if dependency == target {
+				return true
+			}
+			if !seen[dependency] {
+				seen[dependency] = true
+				pending = append(pending, dependency)
+			}
+		}
+	}
+	return false
+}
+
+// findCycle returns the action names of the first dependency cycle found in the graph
+func findCycle(nodes []*stepNode) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(nodes))
+	var path []int
+	var cycle []string
+
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = visiting
+		path = append(path, i)
+
+		for _, next := range nodes[i].dependents {
+			if state[next] == visiting {
+				start := 0
+				for start < len(path) && path[start] != next {
+					start++
+				}
+				for _, j := range path[start:] {
+					cycle = append(cycle, nodes[j].step.Action.Name)
+				}
+				cycle = append(cycle, nodes[next].step.Action.Name)
+				return true
+			}
+			if state[next] == unvisited && visit(next) {
+				return true
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = visited
+		return false
+	}
+
+	for i := range nodes {
+		if state[i] == unvisited && visit(i) {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+const (
+	policyFailFast       = "fail_fast"
+	policyAllMustSucceed = "all_must_succeed"
+	policyQuorum         = "quorum"
+	policyBestEffort     = "best_effort"
+)
+
+// runStepGraph starts every step as soon as all of its dependencies succeeded,
+// were skipped or failed with continue_on_error. Steps whose dependencies did
+// not succeed are never started. A canceled,
+// noPatternMatch or timeout result stops the scheduling of new steps, a failed
+// step only does so with the fail_fast policy, which also cancels the running steps.
+// It returns the aggregated execution status and whether every step was started.
+func runStepGraph(ctx context.Context, nodes []*stepNode, aggregation config.AggregationConfig, process func(ctx context.Context, step shared_models.ExecutionSteps) stepResult) (status string, complete bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered so finished steps never block on the scheduler
+	results := make(chan stepResult, len(nodes))
+	remaining := make([]int, len(nodes))
+	started := 0
+	running := 0
+	stopped := false
+
+	start := func(i int) {
+		started++
+		running++
+		go func() {
+			result := process(ctx, nodes[i].step)
+			result.index = i
+			results <- result
+		}()
+	}
+
+	for i, node := range nodes {
+		remaining[i] = len(node.dependsOn)
+		if remaining[i] == 0 {
+			start(i)
+		}
+	}
+
+	var processed []stepResult
+	for running > 0 {
+		result := <-results
+		running--
+		processed = append(processed, result)
+
+		switch result.status() {
+		case "success", "skipped", "warning":
+			if stopped {
+				continue
+			}
+			for _, dependent := range nodes[result.index].dependents {
+				remaining[dependent]--
+				if remaining[dependent] == 0 {
+					start(dependent)
+				}
+			}
+		case "canceled", "noPatternMatch", "timeout":
+			stopped = true
+		default:
+			if aggregation.Policy == policyFailFast {
+				stopped = true
+				cancel()
+			}
+		}
+	}
+
+	return aggregateStatus(processed, len(nodes), aggregation), started == len(nodes)
+}
+
+// aggregateStatus applies the aggregation policy to the results of the processed steps
+func aggregateStatus(results []stepResult, total int, aggregation config.AggregationConfig) string {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.status()]++
+	}
+
+	// a timeout always fails the execution
+	if counts["timeout"] > 0 {
+		return "error"
+	}
+
+	switch aggregation.Policy {
+	case policyQuorum, policyBestEffort:
+		if counts["canceled"] > 0 {
+			return "canceled"
+		}
+		if counts["noPatternMatch"] > 0 {
+			return "noPatternMatch"
+		}
+		if aggregation.Policy == policyQuorum && (counts["success"]+counts["skipped"])*100 < total*aggregation.Quorum {
+			return "error"
+		}
+		if counts["error"] > 0 || counts["warning"] > 0 {
+			return "successWithWarnings"
+		}
+		return "success"
+	default:
+		for _, status := range []string{"error", "canceled", "noPatternMatch"} {
+			if counts[status] > 0 {
+				return status
+			}
+		}
+		if counts["warning"] > 0 {
+			return "successWithWarnings"
+		}
+		return "success"
+	}
+}
@@ -0,0 +1,197 @@
+package internal_executions
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/plugins"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// testSteps returns a step per action, actions are given as "name" or "name:dep1,dep2"
+func testSteps(actions ...string) []shared_models.ExecutionSteps {
+	var steps []shared_models.ExecutionSteps
+	for _, action := range actions {
+		name, dependencies, _ := strings.Cut(action, ":")
+		step := shared_models.ExecutionSteps{
+			Action: shared_models.Action{ID: uuid.New(), Name: name},
+		}
+		if dependencies != "" {
+			step.Action.Params = []shared_models.Params{{Key: paramDependsOn, Value: dependencies}}
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func TestBuildStepGraph(t *testing.T) {
+	tests := []struct {
+		name     string
+		parallel bool
+		actions  []string
+		// want are the dependencies of every node
+		want    [][]int
+		wantErr string
+	}{
+		{
+			name:    "sequential",
+			actions: []string{"a", "b", "c"},
+			want:    [][]int{nil, {0}, {1}},
+		},
+		{
+			name:     "parallel",
+			parallel: true,
+			actions:  []string{"a", "b", "c"},
+			want:     [][]int{nil, nil, nil},
+		},
+		{
+			name:    "explicit dependencies replace the predecessor",
+			actions: []string{"a", "b", "c:a"},
+			want:    [][]int{nil, {0}, {0}},
+		},
+		{
+			name:    "earlier action depends on a later one",
+			actions: []string{"a", "b:c", "c"},
+			want:    [][]int{nil, {2}, {0}},
+		},
+		{
+			name:    "first action depends on a later one",
+			actions: []string{"a:b", "b"},
+			want:    [][]int{{1}, nil},
+		},
+		{
+			name:    "transitive dependency on a later action",
+			actions: []string{"a", "b:c", "c:d", "d"},
+			want:    [][]int{nil, {2}, {3}, {0}},
+		},
+		{
+			name:     "parallel with dependencies",
+			parallel: true,
+			actions:  []string{"a", "b:a", "c:a,b"},
+			want:     [][]int{nil, {0}, {0, 1}},
+		},
+		{
+			name:    "cycle",
+			actions: []string{"a:c", "b:a", "c:b"},
+			wantErr: "dependency cycle",
+		},
+		{
+			name:    "unknown action",
+			actions: []string{"a", "b:x"},
+			wantErr: "unknown action x",
+		},
+		{
+			name:    "ambiguous name",
+			actions: []string{"a", "a", "b:a"},
+			wantErr: "more than one action",
+		},
+		{
+			name:    "self dependency",
+			actions: []string{"a:a"},
+			wantErr: "depends on itself",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := buildStepGraph(shared_models.Flows{ExecParallel: tt.parallel}, testSteps(tt.actions...))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, node := range nodes {
+				if !reflect.DeepEqual(node.dependsOn, tt.want[i]) {
+					t.Errorf("%s depends on %v, want %v", node.step.Action.Name, node.dependsOn, tt.want[i])
+				}
+				for _, dependency := range node.dependsOn {
+					found := false
+					for _, dependent := range nodes[dependency].dependents {
+						found = found || dependent == i
+					}
+					if !found {
+						t.Errorf("%s is no dependent of %s", node.step.Action.Name, nodes[dependency].step.Action.Name)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	// graph builds nodes named by their index from the dependents of every node
+	graph := func(dependents ...[]int) []*stepNode {
+		nodes := make([]*stepNode, len(dependents))
+		for i := range dependents {
+			nodes[i] = &stepNode{
+				step:       shared_models.ExecutionSteps{Action: shared_models.Action{Name: string(rune('a' + i))}},
+				dependents: dependents[i],
+			}
+		}
+		return nodes
+	}
+
+	tests := []struct {
+		name  string
+		nodes []*stepNode
+		want  []string
+	}{
+		{name: "empty", nodes: graph(), want: nil},
+		{name: "chain", nodes: graph([]int{1}, []int{2}, nil), want: nil},
+		{name: "diamond", nodes: graph([]int{1, 2}, []int{3}, []int{3}, nil), want: nil},
+		{name: "two nodes", nodes: graph([]int{1}, []int{0}), want: []string{"a", "b", "a"}},
+		{name: "cycle behind a chain", nodes: graph([]int{1}, []int{2}, []int{3}, []int{1}), want: []string{"b", "c", "d", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findCycle(tt.nodes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregateStatus(t *testing.T) {
+	success := stepResult{success: true}
+	failed := stepResult{}
+	soft := stepResult{soft: true, err: errors.New("failed")}
+	skipped := stepResult{success: true, res: plugins.Response{Data: map[string]interface{}{"status": "skipped"}}}
+	canceled := stepResult{res: plugins.Response{Data: map[string]interface{}{"status": "canceled"}}}
+	timeout := stepResult{err: context.DeadlineExceeded}
+
+	tests := []struct {
+		name    string
+		policy  string
+		quorum  int
+		results []stepResult
+		total   int
+		want    string
+	}{
+		{name: "all succeeded", policy: policyFailFast, results: []stepResult{success, skipped}, total: 2, want: "success"},
+		{name: "failed", policy: policyAllMustSucceed, results: []stepResult{success, failed}, total: 2, want: "error"},
+		{name: "soft failure", policy: policyFailFast, results: []stepResult{success, soft}, total: 2, want: "successWithWarnings"},
+		{name: "canceled", policy: policyFailFast, results: []stepResult{success, canceled}, total: 2, want: "canceled"},
+		{name: "timeout fails best effort", policy: policyBestEffort, results: []stepResult{success, timeout}, total: 2, want: "error"},
+		{name: "best effort with failure", policy: policyBestEffort, results: []stepResult{success, failed}, total: 2, want: "successWithWarnings"},
+		{name: "quorum reached", policy: policyQuorum, quorum: 50, results: []stepResult{success, failed}, total: 2, want: "successWithWarnings"},
+		{name: "quorum missed", policy: policyQuorum, quorum: 75, results: []stepResult{success, failed}, total: 2, want: "error"},
+		{name: "quorum counts steps not started", policy: policyQuorum, quorum: 50, results: []stepResult{success}, total: 3, want: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateStatus(tt.results, tt.total, config.AggregationConfig{Policy: tt.policy, Quorum: tt.quorum})
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package internal_executions
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/executions"
+	"github.com/v1Flows/runner/pkg/platform"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// sendErrorStep adds a finished step with status error to the execution to
+// report problems which are not caused by a single action
+func sendErrorStep(cfg config.Config, execution shared_models.Executions, name string, lines ...string) {
+	sendRunnerStep(cfg, execution, name, "error", "danger", lines...)
+}
+
+// sendRunnerStep adds a finished step with the given status to the execution
+func sendRunnerStep(cfg config.Config, execution shared_models.Executions, name string, status string, color string, lines ...string) {
+	targetPlatform, ok := platform.GetPlatformForExecution(execution.ID.String())
+	if !ok {
+		log.Error("Failed to get platform")
+		return
+	}
+
+	message := shared_models.Message{
+		Title: name,
+	}
+	for _, line := range lines {
+		message.Lines = append(message.Lines, shared_models.Line{
+			Content: line,
+			Color:   color,
+		})
+	}
+
+	step := shared_models.ExecutionSteps{
+		Action: shared_models.Action{
+			Name:     name,
+			Version:  "1.0.0",
+			Icon:     "solar:danger-triangle-bold-duotone",
+			Category: "runner",
+		},
+		ExecutionID: execution.ID.String(),
+		Messages:    []shared_models.Message{message},
+		Status:      status,
+		RunnerID:    execution.RunnerID,
+		CreatedAt:   time.Now(),
+		StartedAt:   time.Now(),
+		FinishedAt:  time.Now(),
+	}
+
+	if _, err := executions.SendStep(cfg, execution, step, targetPlatform); err != nil {
+		log.Error(err)
+	}
+}
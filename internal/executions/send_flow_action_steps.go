@@ -8,8 +8,10 @@ import (
 	shared_models "github.com/v1Flows/shared-library/pkg/models"
 )
 
-// SendFlowActionSteps sends all active flow actions to alertflow
-func sendFlowActionSteps(cfg config.Config, execution shared_models.Executions, flow shared_models.Flows) (stepsWithIDs []shared_models.ExecutionSteps, err error) {
+// SendFlowActionSteps sends all active flow actions of the given hook group to alertflow.
+// An empty hook selects the regular actions. Actions which already have a step in
+// existing, keyed by action ID, reuse it instead of sending a new one.
+func sendFlowActionSteps(cfg config.Config, execution shared_models.Executions, flow shared_models.Flows, hook string, existing map[string]shared_models.ExecutionSteps) (stepsWithIDs []shared_models.ExecutionSteps, err error) {
 	targetPlatform, ok := platform.GetPlatformForExecution(execution.ID.String())
 	if !ok {
 		log.Error("Failed to get platform")
@@ -17,7 +19,7 @@ func sendFlowActionSteps(cfg config.Config, execution shared_models.Executions,
 	}
 
 	for _, action := range flow.Actions {
-		if !action.Active {
+		if !action.Active || actionHook(action) != hook {
 			continue
 		}
 
@@ -32,6 +34,12 @@ func sendFlowActionSteps(cfg config.Config, execution shared_models.Executions,
 			step.Action.Name = action.CustomName
 		}
 
+		if resumed, ok := existing[action.ID.String()]; ok {
+			resumed.Action = step.Action
+			stepsWithIDs = append(stepsWithIDs, resumed)
+			continue
+		}
+
 		stepID, err := executions.SendStep(cfg, execution, step, targetPlatform)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,134 @@
+package internal_executions
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/executions"
+	"github.com/v1Flows/runner/pkg/platform"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pipelineData is available to the param templates of the pipeline steps
+type pipelineData struct {
+	AlertID     string
+	FlowID      string
+	ExecutionID string
+	RunnerID    string
+	Platform    string
+}
+
+// sendInitialSteps sends the pick up step and the pipeline steps of the platform
+// which run before the actions of the flow
+func sendInitialSteps(cfg config.Config, actions []shared_models.Action, execution shared_models.Executions, targetPlatform string, alertID string) ([]shared_models.ExecutionSteps, error) {
+	initialSteps := []shared_models.ExecutionSteps{
+		{
+			Action: shared_models.Action{
+				Name:        "Runner Pick Up",
+				Description: "Runner picked up the execution",
+				Version:     "1.0.0",
+				Icon:        "solar:rocket-2-bold-duotone",
+				Category:    "runner",
+			},
+			Messages: []shared_models.Message{
+				{
+					Title: "Runner Pick Up",
+					Lines: []shared_models.Line{
+						{
+							Content: execution.RunnerID + " picked up the execution",
+						},
+					},
+				},
+			},
+			Status:     "success",
+			RunnerID:   execution.RunnerID,
+			CreatedAt:  time.Now(),
+			StartedAt:  time.Now(),
+			FinishedAt: time.Now(),
+		},
+	}
+
+	data := pipelineData{
+		AlertID:     alertID,
+		FlowID:      execution.FlowID,
+		ExecutionID: execution.ID.String(),
+		RunnerID:    execution.RunnerID,
+		Platform:    targetPlatform,
+	}
+
+	driver, err := platform.Get(cfg, targetPlatform)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	for _, pipelineStep := range driver.Pipeline() {
+		params, err := renderPipelineParams(pipelineStep, data)
+		if err != nil {
+			log.Errorf("Invalid pipeline step %s for execution %s: %v", pipelineStep.Plugin, execution.ID, err)
+			sendErrorStep(cfg, execution, "Invalid Pipeline Step", err.Error(), "Cancel execution")
+			return nil, err
+		}
+
+		initialSteps = append(initialSteps, shared_models.ExecutionSteps{
+			Action: shared_models.Action{
+				Plugin: pipelineStep.Plugin,
+				Params: params,
+			},
+			Status:    "pending",
+			CreatedAt: time.Now(),
+		})
+	}
+
+	for i, step := range initialSteps {
+		step.ExecutionID = execution.ID.String()
+
+		// get action plugin info
+		for _, action := range actions {
+			if action.Plugin == step.Action.Plugin {
+				if step.Action.Name == "" || step.Action.Description == "" {
+					step.Action.Name = action.Name
+					step.Action.Description = action.Description
+					step.Action.Version = action.Version
+					step.Action.Icon = action.Icon
+					step.Action.Category = action.Category
+				}
+			}
+		}
+
+		stepID, err := executions.SendStep(cfg, execution, step, targetPlatform)
+		if err != nil {
+			return nil, err
+		}
+		step.ID = stepID.ID
+		initialSteps[i] = step
+	}
+	return initialSteps, nil
+}
+
+// renderPipelineParams renders the param templates of a pipeline step
+func renderPipelineParams(step config.PipelineStepConfig, data pipelineData) ([]shared_models.Params, error) {
+	var params []shared_models.Params
+	for _, param := range step.Params {
+		tmpl, err := template.New(param.Key).Option("missingkey=error").Parse(param.Value)
+		if err != nil {
+			return nil, fmt.Errorf("param %s: %w", param.Key, err)
+		}
+
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return nil, fmt.Errorf("param %s: %w", param.Key, err)
+		}
+
+		params = append(params, shared_models.Params{
+			Key:   param.Key,
+			Value: rendered.String(),
+		})
+	}
+	return params, nil
+}
@@ -1,6 +1,8 @@
 package internal_executions
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -8,17 +10,17 @@ import (
 	"github.com/google/uuid"
 	bmodels "github.com/v1Flows/alertFlow/services/backend/pkg/models"
 	"github.com/v1Flows/runner/config"
-	internal_alertflow "github.com/v1Flows/runner/internal/alertflow"
-	internal_exflow "github.com/v1Flows/runner/internal/exflow"
+	"github.com/v1Flows/runner/internal/journal"
 	"github.com/v1Flows/runner/internal/runner"
 	"github.com/v1Flows/runner/pkg/executions"
+	platformfn "github.com/v1Flows/runner/pkg/platform"
 	"github.com/v1Flows/runner/pkg/plugins"
 	shared_models "github.com/v1Flows/shared-library/pkg/models"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func startProcessing(platform string, cfg config.Config, actions []shared_models.Action, loadedPlugins map[string]plugins.Plugin, execution shared_models.Executions, alertID string) {
+func startProcessing(parent context.Context, platform string, cfg config.Config, actions []shared_models.Action, loadedPlugins map[string]plugins.Plugin, execution shared_models.Executions, alertID string, resume bool) {
 	configManager := config.GetInstance()
 
 	// ensure that execution runnerid equals the config runnerid
@@ -34,8 +36,13 @@ func startProcessing(platform string, cfg config.Config, actions []shared_models
 		log.Error("Error creating workspace dir: ", err)
 	}
 
+	// set runner to busy, every end of the execution releases it again
+	runner.Busy(platform, cfg, true)
+
 	execution.Status = "running"
-	execution.ExecutedAt = time.Now()
+	if !resume {
+		execution.ExecutedAt = time.Now()
+	}
 
 	err = executions.UpdateExecution(cfg, execution, platform)
 	if err != nil {
@@ -43,187 +50,276 @@ func startProcessing(platform string, cfg config.Config, actions []shared_models
 		return
 	}
 
-	// set runner to busy
-	runner.Busy(platform, cfg, true)
+	// the journal is kept if the runner crashes so the execution can be recovered on restart
+	journal.Begin(platform, execution, alertID)
+	// finished is set once the execution ended, after a panic the journal is kept
+	finished := false
+	defer func() {
+		if finished {
+			journal.Finish(execution.ID.String())
+		}
+	}()
+	end := func(endExecution func(config.Config, shared_models.Executions, string)) {
+		endExecution(cfg, execution, platform)
+		finished = true
+	}
 
-	// send initial step
-	var initialSteps []shared_models.ExecutionSteps
-	if platform == "alertflow" {
-		initialSteps, err = internal_alertflow.SendInitialSteps(cfg, actions, execution, alertID)
-		if err != nil {
-			executions.EndWithError(cfg, execution, platform)
+	// a resumed execution reuses the flow action steps it created before the restart
+	var existingSteps map[string]shared_models.ExecutionSteps
+	var finishedSteps map[string]journal.StepRecord
+	if resume {
+		existingSteps, finishedSteps = resumableSteps(cfg, execution, platform)
+	}
+
+	ctx, cancelExecution := context.WithCancelCause(parent)
+	defer cancelExecution(nil)
+	if cfg.ExecutionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.ExecutionTimeout)
+		defer cancel()
+	}
+
+	concurrency.track(execution, cancelExecution)
+	defer concurrency.untrack(execution.ID.String())
+
+	gate := newPauseGate()
+	go watchExecution(ctx, cancelExecution, gate, cfg, execution, platform)
+
+	// pauseRequested pauses the execution before the next step if a step asked for it
+	pauseRequested := func(res plugins.Response) {
+		if res.Data["status"] != "paused" {
 			return
 		}
-	} else if platform == "exflow" {
-		initialSteps, err = internal_exflow.SendInitialSteps(cfg, actions, execution)
-		if err != nil {
-			executions.EndWithError(cfg, execution, platform)
-			return
+		executions.SetToPaused(cfg, execution, platform)
+		journal.Transition(execution.ID.String(), "paused")
+		if gate.pause() {
+			log.Infof("Execution %s paused by step", execution.ID)
 		}
 	}
 
+	// send the initial steps of the pre-execution pipeline
+	initialSteps, err := sendInitialSteps(cfg, actions, execution, platform, alertID)
+	if err != nil {
+		end(executions.EndWithError)
+		return
+	}
+
+	// only executions of platforms with alerts collect their alert
+	withAlerts := platformfn.Alerts(cfg, platform)
+
 	// process each initial step where pending is true
 	var flow shared_models.Flows
 	var flowBytes []byte
 	var alert bmodels.Alerts
+	outputs := newExecutionOutputs()
+	// a sub flow waiting for this execution gets its outputs once it ended
+	defer childOutputs.store(execution.ID.String(), outputs)
 	for _, step := range initialSteps {
 		if step.Status == "pending" {
-			res, success, err := processStep(cfg, workspace, actions, loadedPlugins, flow, flowBytes, alert, initialSteps, step, execution)
+			if err := gate.wait(ctx); err != nil {
+				cancelRemainingSteps(cfg, execution.ID.String())
+				if executionCanceled(ctx) {
+					end(executions.EndCanceled)
+					return
+				}
+				end(executions.EndWithError)
+				return
+			}
+
+			journal.Step(execution.ID.String(), step, "running", nil)
+			res, success, err := processStep(ctx, cfg, workspace, actions, loadedPlugins, flow, flowBytes, alert, outputs, initialSteps, step, execution)
+			result := stepResult{res: res, success: success, err: err}
+			journal.Step(execution.ID.String(), step, result.status(), res.Data)
+			outputs.record(step, result)
+			pauseRequested(res)
 			if err != nil {
 				log.Error("Error processing initial step: ", err)
 				// cancel remaining steps
 				cancelRemainingSteps(cfg, execution.ID.String())
 				// end execution
-				executions.EndWithError(cfg, execution, platform)
+				if executionCanceled(ctx) {
+					end(executions.EndCanceled)
+					return
+				}
+				end(executions.EndWithError)
 				return
 			}
 
+			// the flow and the alert are loaded by collect_data, steps before it run without them
 			if res.Flow != nil {
 				flow = *res.Flow
-			} else if flow.ID == uuid.Nil {
-				log.Error("Error parsing flow")
-				cancelRemainingSteps(cfg, execution.ID.String())
-				executions.EndWithError(cfg, execution, platform)
-				return
 			}
-
 			if len(res.FlowBytes) > 0 {
 				flowBytes = res.FlowBytes
 			}
-
-			if platform == "alertflow" && res.Alert != nil {
+			if withAlerts && res.Alert != nil {
 				alert = *res.Alert
-			} else if platform == "alertflow" && alert.ID == uuid.Nil {
-				log.Error("Error parsing alert")
-				cancelRemainingSteps(cfg, execution.ID.String())
-				executions.EndWithError(cfg, execution, platform)
-				return
 			}
 
 			if res.Data["status"] == "noPatternMatch" {
 				cancelRemainingSteps(cfg, execution.ID.String())
-				executions.EndNoPatternMatch(cfg, execution, platform)
+				end(executions.EndNoPatternMatch)
 				return
 			}
 
 			if res.Data["status"] == "canceled" {
 				cancelRemainingSteps(cfg, execution.ID.String())
-				executions.EndCanceled(cfg, execution, platform)
+				end(executions.EndCanceled)
 				return
 			}
 
 			if !success {
 				cancelRemainingSteps(cfg, execution.ID.String())
-				executions.EndWithError(cfg, execution, platform)
+				end(executions.EndWithError)
 				return
 			}
 		}
 	}
 
-	// send flow actions as steps to alertflow
-	flowActionStepsWithIDs, err := sendFlowActionSteps(cfg, execution, flow)
-	if err != nil {
-		executions.EndWithError(cfg, execution, platform)
+	if flow.ID == uuid.Nil {
+		log.Error("Error parsing flow")
+		cancelRemainingSteps(cfg, execution.ID.String())
+		end(executions.EndWithError)
+		return
+	}
+	if withAlerts && alert.ID == uuid.Nil {
+		log.Error("Error parsing alert")
+		cancelRemainingSteps(cfg, execution.ID.String())
+		end(executions.EndWithError)
 		return
 	}
 
-	if !flow.ExecParallel {
-		// process each flow action step in sequential order where pending is true
-		for _, step := range flowActionStepsWithIDs {
-			if step.Status == "pending" {
-				res, success, err := processStep(cfg, workspace, actions, loadedPlugins, flow, flowBytes, alert, flowActionStepsWithIDs, step, execution)
-				if err != nil {
-					// cancel remaining steps
-					cancelRemainingSteps(cfg, execution.ID.String())
-					// end execution
-					executions.EndWithError(cfg, execution, platform)
-					return
-				}
+	if dryRun(cfg, flow) && !resume {
+		log.Infof("Execution %s is a dry run", execution.ID)
+		sendRunnerStep(cfg, execution, "Dry Run", "success", "warning", "Steps are validated, plugins supporting dry runs are asked to make no side effects and all others are not called")
+	}
 
-				if res.Data["status"] == "noPatternMatch" {
-					cancelRemainingSteps(cfg, execution.ID.String())
-					executions.EndNoPatternMatch(cfg, execution, platform)
-					return
-				}
+	// executions of the same concurrency group do not run their flow actions at the same time
+	if group, ok := flowConcurrencyGroup(cfg, flow); ok {
+		// an empty key disables the group
+		key, err := renderTemplate("concurrency_key", group.Key, outputs.templateData(flow, alert, execution))
+		if err != nil {
+			log.Errorf("Invalid concurrency key for execution %s: %v", execution.ID, err)
+			sendErrorStep(cfg, execution, "Invalid Concurrency Key", err.Error(), "Cancel execution")
+			end(executions.EndWithError)
+			return
+		}
 
-				if res.Data["status"] == "canceled" {
-					cancelRemainingSteps(cfg, execution.ID.String())
-					executions.EndCanceled(cfg, execution, platform)
+		if key != "" {
+			acquired, err := concurrency.acquire(ctx, key, execution, group.Policy)
+			if err != nil {
+				if executionCanceled(ctx) {
+					end(executions.EndCanceled)
 					return
 				}
-
-				if !success {
-					cancelRemainingSteps(cfg, execution.ID.String())
-					executions.EndWithError(cfg, execution, platform)
-					return
+				if errors.Is(err, errHeldByParent) {
+					log.Errorf("Execution %s can not acquire its concurrency key: %v", execution.ID, err)
+					sendErrorStep(cfg, execution, "Concurrency", err.Error(), "Cancel execution")
 				}
+				end(executions.EndWithError)
+				return
 			}
-		}
-	} else {
-		var executedSteps int
-		var failedSteps int
-		var noPatternMatchSteps int
-		var canceledSteps int
-		var successSteps int
-		// process each flow action step in parallel where pending is true
-		for _, step := range flowActionStepsWithIDs {
-			if step.Status == "pending" {
-				go func() {
-					res, success, err := processStep(cfg, workspace, actions, loadedPlugins, flow, flowBytes, alert, flowActionStepsWithIDs, step, execution)
-					if err != nil {
-						failedSteps++
-					}
-
-					executedSteps++
-
-					if res.Data["status"] == "noPatternMatch" {
-						noPatternMatchSteps++
-					}
-
-					if res.Data["status"] == "canceled" {
-						canceledSteps++
-					}
-
-					if !success {
-						failedSteps++
-					}
-
-					if success {
-						successSteps++
-					}
-				}()
+			if !acquired {
+				log.Infof("Skip execution %s, concurrency key %s is held by another execution", execution.ID, key)
+				sendRunnerStep(cfg, execution, "Concurrency", "canceled", "warning", "Concurrency key "+key+" is held by another execution", "Skip execution")
+				end(executions.EndCanceled)
+				return
 			}
+			defer concurrency.release(key, execution.ID.String())
 		}
+	}
 
-		// wait for all steps to finish
-		for executedSteps < len(flowActionStepsWithIDs) {
-			if executedSteps == len(flowActionStepsWithIDs) {
-				break
-			}
+	// runGroup sends the actions of a hook group as steps and processes them
+	runGroup := func(ctx context.Context, hook string) (string, bool) {
+		steps, err := sendFlowActionSteps(cfg, execution, flow, hook, existingSteps)
+		if err != nil {
+			return "error", false
+		}
+		if len(steps) == 0 {
+			return "success", true
 		}
 
-		if failedSteps > 0 {
-			executions.EndWithError(cfg, execution, platform)
-			return
+		graph, err := buildStepGraph(flow, steps)
+		if err != nil {
+			log.Errorf("Invalid action graph for execution %s: %v", execution.ID, err)
+			sendErrorStep(cfg, execution, "Invalid Action Graph", err.Error(), "Cancel execution")
+			return "error", false
 		}
 
-		if canceledSteps > 0 {
-			executions.EndCanceled(cfg, execution, platform)
-			return
+		return runStepGraph(ctx, graph, cfg.Aggregation, func(ctx context.Context, step shared_models.ExecutionSteps) stepResult {
+			// a paused execution continues with the next pending step once it is resumed
+			if err := gate.wait(ctx); err != nil {
+				return stepResult{err: err}
+			}
+
+			// steps which finished before a restart keep their result
+			if journaled, ok := finishedSteps[step.ID.String()]; ok {
+				result := stepResult{res: plugins.Response{Data: journaled.Data, Success: true}, success: true}
+				outputs.record(step, result)
+				return result
+			}
+
+			journal.Step(execution.ID.String(), step, "running", nil)
+			res, success, err := processStep(ctx, cfg, workspace, actions, loadedPlugins, flow, flowBytes, alert, outputs, steps, step, execution)
+			result := stepResult{res: res, success: success, err: err, soft: actionParamBool(step.Action, paramContinueOnError)}
+			journal.Step(execution.ID.String(), step, result.status(), res.Data)
+			outputs.record(step, result)
+			pauseRequested(res)
+			return result
+		})
+	}
+
+	status, complete := runGroup(ctx, "")
+
+	// steps blocked by a failed dependency or an interrupted pause are still pending
+	if !complete || ctx.Err() != nil {
+		cancelRemainingSteps(cfg, execution.ID.String())
+	}
+
+	if executionCanceled(ctx) {
+		status = "canceled"
+	}
+
+	// hooks also run after the execution timed out or was canceled, but not once the runner shuts down
+	hookCtx, cancelHooks := hookContext(parent, cfg)
+	defer cancelHooks()
+	for _, hook := range hookGroups(status) {
+		if shutDown(hookCtx) {
+			log.Infof("Skip %s hooks of execution %s, the runner shuts down", hook, execution.ID)
+			break
 		}
 
-		if noPatternMatchSteps > 0 {
-			executions.EndNoPatternMatch(cfg, execution, platform)
-			return
+		hookStatus, complete := runGroup(hookCtx, hook)
+		if !complete {
+			cancelRemainingSteps(cfg, execution.ID.String())
+		}
+
+		// a failing hook fails an otherwise successful execution
+		if hookStatus != "success" && hookStatus != "successWithWarnings" && (status == "success" || status == "successWithWarnings") {
+			status = "error"
+		} else if hookStatus == "successWithWarnings" && status == "success" {
+			status = hookStatus
 		}
 	}
 
-	executions.EndSuccess(cfg, execution, platform)
+	switch status {
+	case "error":
+		end(executions.EndWithError)
+		return
+	case "canceled":
+		end(executions.EndCanceled)
+		return
+	case "noPatternMatch":
+		end(executions.EndNoPatternMatch)
+		return
+	case "successWithWarnings":
+		end(executions.EndSuccessWithWarnings)
+	default:
+		end(executions.EndSuccess)
+	}
 
 	err = os.RemoveAll(fmt.Sprintf("%s/%s", cfg.WorkspaceDir, execution.ID))
 	if err != nil {
 		log.Error("Error deleting workspace dir: ", err)
 	}
-
-	runner.Busy(platform, cfg, false)
 }
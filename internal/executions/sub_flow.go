@@ -0,0 +1,403 @@
+package internal_executions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/executions"
+	"github.com/v1Flows/runner/pkg/platform"
+	"github.com/v1Flows/runner/pkg/plugins"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	subFlowPlugin  = "sub_flow"
+	subFlowVersion = "1.0.0"
+
+	// subFlowPollInterval is how often the child execution is checked
+	subFlowPollInterval = 5 * time.Second
+	// subFlowWaitTimeout is how long to wait for the child execution without a wait_timeout
+	subFlowWaitTimeout = time.Hour
+)
+
+// childOutputs hands the outputs of child executions to the sub flows waiting for them
+var childOutputs = newEndedOutputs()
+
+// endedOutputs keeps the step outputs of the executions sub flows wait for
+type endedOutputs struct {
+	mu      sync.Mutex
+	waiting map[string]chan struct{}
+	outputs map[string]map[string]stepOutput
+}
+
+func newEndedOutputs() *endedOutputs {
+	return &endedOutputs{
+		waiting: make(map[string]chan struct{}),
+		outputs: make(map[string]map[string]stepOutput),
+	}
+}
+
+// expect registers a sub flow waiting for the execution, the channel is closed once it ended on this runner
+func (e *endedOutputs) expect(executionID string) <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ended := make(chan struct{})
+	e.waiting[executionID] = ended
+	return ended
+}
+
+// store keeps the outputs of an ended execution if a sub flow waits for it
+func (e *endedOutputs) store(executionID string, outputs *executionOutputs) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ended, ok := e.waiting[executionID]
+	if !ok {
+		return
+	}
+
+	outputs.mu.RLock()
+	steps := make(map[string]stepOutput, len(outputs.steps))
+	for key, output := range outputs.steps {
+		steps[key] = output
+	}
+	outputs.mu.RUnlock()
+
+	e.outputs[executionID] = steps
+	delete(e.waiting, executionID)
+	close(ended)
+}
+
+// take returns the outputs of the execution by plugin, action name and action ID
+func (e *endedOutputs) take(executionID string) map[string]stepOutput {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	outputs := e.outputs[executionID]
+	delete(e.outputs, executionID)
+	return outputs
+}
+
+// forget drops the registration of a sub flow which stopped waiting
+func (e *endedOutputs) forget(executionID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.waiting, executionID)
+	delete(e.outputs, executionID)
+}
+
+// subFlow is a built-in action which starts an execution of another flow and
+// optionally waits for it. The step mirrors the final status of the child execution.
+type subFlow struct{}
+
+// RegisterBuiltinPlugins adds the actions which are executed by the runner itself
+// to the loaded plugins and returns the plugin lists extended by them
+func RegisterBuiltinPlugins(cfg config.Config, loadedPlugins map[string]plugins.Plugin, modelPlugins []shared_models.Plugin, actionPlugins []shared_models.Plugin) ([]shared_models.Plugin, []shared_models.Plugin) {
+	builtins := map[string]plugins.Plugin{
+		subFlowPlugin: &subFlow{},
+	}
+
+	for name, plugin := range builtins {
+		info, err := plugin.Info(plugins.InfoRequest{Config: cfg.WithoutPlatforms(), Workspace: cfg.WorkspaceDir})
+		if err != nil {
+			log.Errorf("Error getting info for built-in plugin %s: %v", name, err)
+			continue
+		}
+
+		loadedPlugins[name] = plugin
+		modelPlugins = append(modelPlugins, info)
+		actionPlugins = append(actionPlugins, info)
+	}
+
+	return modelPlugins, actionPlugins
+}
+
+// SupportsDryRun declares that a dry run does not start the flow
+func (s *subFlow) SupportsDryRun() bool {
+	return true
+}
+
+func (s *subFlow) Info(request plugins.InfoRequest) (shared_models.Plugin, error) {
+	return shared_models.Plugin{
+		Name:    "Sub-Flow",
+		Type:    "action",
+		Version: subFlowVersion,
+		Author:  "v1Flows",
+		Action: shared_models.Action{
+			Name:        "Sub-Flow",
+			Description: "Start an execution of another flow and wait for it",
+			Plugin:      subFlowPlugin,
+			Icon:        "solar:routing-2-bold-duotone",
+			Category:    "Flow",
+			Params: []shared_models.Params{
+				{
+					Key:         "flow_id",
+					Title:       "Flow ID",
+					Description: "ID of the flow to execute",
+					Type:        "text",
+					Required:    true,
+				},
+				{
+					Key:         "params",
+					Title:       "Params",
+					Description: "JSON object which is passed to the flow as alert payload",
+					Type:        "textarea",
+					Default:     "{}",
+				},
+				{
+					Key:         "wait",
+					Title:       "Wait",
+					Description: "Wait for the execution to finish and take over its status",
+					Type:        "boolean",
+					Default:     "true",
+				},
+				{
+					Key:         "wait_timeout",
+					Title:       "Wait Timeout",
+					Description: "Maximum time to wait for the execution, e.g. 30m",
+					Type:        "text",
+					Default:     "1h",
+				},
+			},
+		},
+	}, nil
+}
+
+func (s *subFlow) EndpointRequest(request plugins.EndpointRequest) (plugins.Response, error) {
+	return plugins.Response{}, errors.New("sub_flow is no endpoint plugin")
+}
+
+func (s *subFlow) ExecuteTask(request plugins.ExecuteTaskRequest) (plugins.Response, error) {
+	return s.ExecuteTaskContext(context.Background(), request)
+}
+
+func (s *subFlow) ExecuteTaskContext(ctx context.Context, request plugins.ExecuteTaskRequest) (plugins.Response, error) {
+	cfg := request.Config
+	step := request.Step
+	action := step.Action
+
+	flowID := strings.TrimSpace(actionParam(action, "flow_id"))
+	if flowID == "" {
+		return plugins.Response{}, errors.New("flow_id is required")
+	}
+
+	params := strings.TrimSpace(actionParam(action, "params"))
+	if params == "" {
+		params = "{}"
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(params), &payload); err != nil {
+		return plugins.Response{}, fmt.Errorf("params is no valid json object: %w", err)
+	}
+
+	waitTimeout := subFlowWaitTimeout
+	if value := strings.TrimSpace(actionParam(action, "wait_timeout")); value != "" {
+		var err error
+		waitTimeout, err = time.ParseDuration(value)
+		if err != nil || waitTimeout <= 0 {
+			return plugins.Response{}, errors.New("wait_timeout must be a duration like 30m")
+		}
+	}
+
+	if request.DryRun {
+		step.Messages = append(step.Messages, shared_models.Message{
+			Title: "Sub-Flow",
+			Lines: []shared_models.Line{
+				{
+					Content: "Dry run, flow " + flowID + " is not started",
+					Color:   "warning",
+				},
+			},
+		})
+		return finishSubFlowStep(cfg, request, step, "success", map[string]interface{}{
+			"flow_id": flowID,
+		})
+	}
+
+	driver, err := platform.GetStarter(cfg, request.Platform)
+	if err != nil {
+		return plugins.Response{}, fmt.Errorf("sub flows cannot be started: %w", err)
+	}
+	runnerID := config.GetInstance().GetRunnerID(request.Platform)
+
+	child, err := driver.StartExecution(ctx, cfg, flowID, runnerID, json.RawMessage(params))
+	if err != nil {
+		return plugins.Response{}, fmt.Errorf("failed to start flow %s: %w", flowID, err)
+	}
+	childID := child.Execution.ID.String()
+
+	step.Messages = append(step.Messages, shared_models.Message{
+		Title: "Sub-Flow",
+		Lines: []shared_models.Line{
+			{
+				Content: "Started execution " + childID + " of flow " + flowID,
+			},
+		},
+	})
+
+	wait := actionParamBool(action, "wait")
+	var ended <-chan struct{}
+	if wait {
+		ended = childOutputs.expect(childID)
+		defer childOutputs.forget(childID)
+		// the child cannot wait for a concurrency key this execution holds
+		concurrency.nest(childID, request.Execution.ID.String())
+		defer concurrency.unnest(childID)
+	}
+
+	// a child assigned to this runner is queued right away instead of waiting for the next poll
+	pool, local := poolFromContext(ctx)
+	local = local && child.Execution.RunnerID == runnerID
+	enqueueChild := func() {
+		if local {
+			platform.SetPlatformForExecution(childID, request.Platform)
+			pool.enqueue([]queuedExecution{{platform: request.Platform, execution: child.Execution, alertID: child.AlertID}})
+		}
+	}
+
+	if !wait {
+		enqueueChild()
+		return finishSubFlowStep(cfg, request, step, "success", map[string]interface{}{
+			"flow_id":      flowID,
+			"execution_id": childID,
+		})
+	}
+
+	step.Messages[len(step.Messages)-1].Lines = append(step.Messages[len(step.Messages)-1].Lines, shared_models.Line{
+		Content: "Waiting up to " + waitTimeout.String() + " for the execution",
+	})
+	if err := executions.UpdateStep(cfg, request.Execution.ID.String(), step, request.Platform); err != nil {
+		log.Error(err)
+	}
+
+	var latest shared_models.Executions
+	waitForChild := func() {
+		enqueueChild()
+		latest, err = awaitSubFlow(ctx, cfg, request.Platform, childID, ended, waitTimeout)
+	}
+	if local {
+		// the slot of this execution is lent to the child, so a full pool does not block it
+		pool.lend(waitForChild)
+	} else {
+		waitForChild()
+	}
+	if err != nil {
+		return plugins.Response{}, err
+	}
+
+	childSteps, err := executions.GetSteps(cfg, childID, request.Platform)
+	if err != nil {
+		log.Warnf("Failed to get steps of sub flow execution %s: %v", childID, err)
+	}
+	outputs := childOutputs.take(childID)
+
+	stepResults := make(map[string]interface{})
+	result := shared_models.Message{
+		Title: "Sub-Flow Result",
+	}
+	for _, childStep := range childSteps {
+		if childStep.ParentID != "" || childStep.Action.Name == "" {
+			continue
+		}
+		stepResult := map[string]interface{}{
+			"status": childStep.Status,
+		}
+		// the outputs are only known if the child was processed by this runner
+		if output, ok := outputs[childStep.Action.ID.String()]; ok {
+			stepResult["data"] = output.Data
+		}
+		stepResults[childStep.Action.Name] = stepResult
+		result.Lines = append(result.Lines, shared_models.Line{
+			Content: childStep.Action.Name + ": " + childStep.Status,
+			Color:   subFlowColor(childStep.Status),
+		})
+	}
+	result.Lines = append(result.Lines, shared_models.Line{
+		Content: "Execution " + childID + " ended with " + latest.Status,
+		Color:   subFlowColor(latest.Status),
+	})
+	step.Messages = append(step.Messages, result)
+
+	return finishSubFlowStep(cfg, request, step, latest.Status, map[string]interface{}{
+		"flow_id":      flowID,
+		"execution_id": childID,
+		"status":       latest.Status,
+		"steps":        stepResults,
+	})
+}
+
+// awaitSubFlow waits until the child execution ended. It is checked right away
+// once it ended on this runner and otherwise every subFlowPollInterval.
+func awaitSubFlow(ctx context.Context, cfg config.Config, targetPlatform string, childID string, ended <-chan struct{}, timeout time.Duration) (shared_models.Executions, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(subFlowPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return shared_models.Executions{}, ctx.Err()
+		case <-deadline.C:
+			return shared_models.Executions{}, fmt.Errorf("execution %s did not end within %s", childID, timeout)
+		case <-ended:
+			// the channel stays closed, later checks wait for the ticker again
+			ended = nil
+		case <-ticker.C:
+		}
+
+		latest, err := executions.GetExecution(cfg, childID, targetPlatform)
+		if err != nil {
+			log.Warnf("Failed to get sub flow execution %s: %v", childID, err)
+			continue
+		}
+		if subFlowEnded(latest.Status) {
+			return latest, nil
+		}
+	}
+}
+
+// finishSubFlowStep stores the final status of the step and returns its result
+func finishSubFlowStep(cfg config.Config, request plugins.ExecuteTaskRequest, step shared_models.ExecutionSteps, status string, data map[string]interface{}) (plugins.Response, error) {
+	step.Status = status
+	step.FinishedAt = time.Now()
+	if err := executions.UpdateStep(cfg, request.Execution.ID.String(), step, request.Platform); err != nil {
+		log.Error(err)
+		return plugins.Response{}, err
+	}
+
+	// a canceled or noPatternMatch status in the data is handled like the one of any other action
+	success := status == "success" || status == "successWithWarnings"
+	return plugins.Response{Data: data, Success: success}, nil
+}
+
+// subFlowEnded reports whether an execution reached a final status
+func subFlowEnded(status string) bool {
+	switch status {
+	case "", "pending", "scheduled", "running", "paused", "interactionWaiting":
+		return false
+	default:
+		return true
+	}
+}
+
+func subFlowColor(status string) string {
+	switch status {
+	case "success", "skipped":
+		return "success"
+	case "successWithWarnings", "warning", "noPatternMatch":
+		return "warning"
+	default:
+		return "danger"
+	}
+}
@@ -0,0 +1,172 @@
+package internal_executions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/google/uuid"
+	af_models "github.com/v1Flows/alertFlow/services/backend/pkg/models"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// stepOutput is the result of a processed step as it is exposed to templates
+type stepOutput struct {
+	Status string
+	Data   map[string]interface{}
+}
+
+// templateAlert exposes the alert with its payload decoded
+type templateAlert struct {
+	af_models.Alerts
+	Payload interface{}
+}
+
+// templateData is the root object action params are rendered with
+type templateData struct {
+	Steps     map[string]stepOutput
+	Alert     templateAlert
+	Flow      shared_models.Flows
+	Execution shared_models.Executions
+	// Item and Index are set while the items of a for_each loop are processed
+	Item  interface{}
+	Index int
+}
+
+// executionOutputs stores the outputs of all processed steps of an execution.
+// Each output is reachable by the plugin name, the action name and the action ID.
+type executionOutputs struct {
+	mu    *sync.RWMutex
+	steps map[string]stepOutput
+
+	inLoop bool
+	item   interface{}
+	index  int
+}
+
+func newExecutionOutputs() *executionOutputs {
+	return &executionOutputs{
+		mu:    &sync.RWMutex{},
+		steps: make(map[string]stepOutput),
+	}
+}
+
+// withItem returns a view on the outputs which exposes a for_each item to templates
+func (o *executionOutputs) withItem(item interface{}, index int) *executionOutputs {
+	return &executionOutputs{
+		mu:     o.mu,
+		steps:  o.steps,
+		inLoop: true,
+		item:   item,
+		index:  index,
+	}
+}
+
+// record stores the output of a processed step
+func (o *executionOutputs) record(step shared_models.ExecutionSteps, result stepResult) {
+	output := stepOutput{
+		Status: result.stepStatus(),
+		Data:   result.res.Data,
+	}
+	if output.Data == nil {
+		output.Data = make(map[string]interface{})
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, key := range []string{step.Action.Plugin, step.Action.Name, step.Action.ID.String()} {
+		if key == "" || key == uuid.Nil.String() {
+			continue
+		}
+		o.steps[key] = output
+	}
+}
+
+// templateData returns a snapshot of the outputs together with the execution data
+func (o *executionOutputs) templateData(flow shared_models.Flows, alert af_models.Alerts, execution shared_models.Executions) templateData {
+	o.mu.RLock()
+	steps := make(map[string]stepOutput, len(o.steps))
+	for key, output := range o.steps {
+		steps[key] = output
+	}
+	o.mu.RUnlock()
+
+	data := templateData{
+		Steps:     steps,
+		Alert:     templateAlert{Alerts: alert},
+		Flow:      flow,
+		Execution: execution,
+		Item:      o.item,
+		Index:     o.index,
+	}
+	if len(alert.Payload) > 0 {
+		// a payload which is no valid json is left empty
+		_ = json.Unmarshal(alert.Payload, &data.Alert.Payload)
+	}
+
+	return data
+}
+
+// Templates of flow actions are enclosed in ${{ }}, so param values for the plugins,
+// e.g. docker format strings, may contain plain Go templates which are passed on as
+// they are. Templates of the runner config use the plain {{ }}.
+const (
+	flowTemplateDelim   = "${{"
+	configTemplateDelim = "{{"
+)
+
+// parseTemplate parses a template with the runner functions, left is its left delimiter
+func parseTemplate(name string, text string, left string) (*template.Template, error) {
+	return template.New(name).Delims(left, "}}").Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+}
+
+// renderActionParams renders every param value which contains a ${{ }} template,
+// all other values are left untouched. References to unknown steps or keys result
+// in an error. The when condition and the for_each list are left as they are since
+// they are evaluated on their own.
+func renderActionParams(action shared_models.Action, data templateData) (shared_models.Action, error) {
+	params := make([]shared_models.Params, len(action.Params))
+	copy(params, action.Params)
+
+	for i, param := range params {
+		if param.Key == paramWhen || param.Key == paramForEach || !strings.Contains(param.Value, flowTemplateDelim) {
+			continue
+		}
+
+		tmpl, err := parseTemplate(param.Key, param.Value, flowTemplateDelim)
+		if err != nil {
+			return action, fmt.Errorf("param %s: %w", param.Key, err)
+		}
+
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return action, fmt.Errorf("param %s: %w", param.Key, err)
+		}
+		params[i].Value = rendered.String()
+	}
+
+	action.Params = params
+	return action, nil
+}
+
+// renderTemplate renders a template from the runner config and trims the result
+func renderTemplate(name string, text string, data templateData) (string, error) {
+	if !strings.Contains(text, configTemplateDelim) {
+		return strings.TrimSpace(text), nil
+	}
+
+	tmpl, err := parseTemplate(name, text, configTemplateDelim)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(rendered.String()), nil
+}
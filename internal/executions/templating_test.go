@@ -0,0 +1,80 @@
+package internal_executions
+
+import (
+	"testing"
+
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+func TestRenderActionParams(t *testing.T) {
+	data := templateData{
+		Steps: map[string]stepOutput{
+			"ping": {Status: "success", Data: map[string]interface{}{"latency": 12}},
+		},
+		Flow: shared_models.Flows{Name: "prod"},
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain value", value: "restart", want: "restart"},
+		{name: "literal go template", value: "docker inspect -f '{{.State.Running}}'", want: "docker inspect -f '{{.State.Running}}'"},
+		{name: "runner template", value: "${{ .Flow.Name }}-${{ .Steps.ping.Data.latency }}", want: "prod-12"},
+		{name: "runner and literal template", value: "${{ .Flow.Name }} {{.ID}}", want: "prod {{.ID}}"},
+		{name: "unknown step", value: "${{ .Steps.missing.Status }}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := shared_models.Action{Params: []shared_models.Params{{Key: "command", Value: tt.value}}}
+
+			rendered, err := renderActionParams(action, data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, want an error", rendered.Params[0].Value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rendered.Params[0].Value; got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if action.Params[0].Value != tt.value {
+				t.Errorf("the params of the action were changed")
+			}
+		})
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	data := templateData{
+		Steps: map[string]stepOutput{"ping": {Status: "success"}},
+	}
+
+	tests := []struct {
+		name string
+		when string
+		want bool
+	}{
+		{name: "empty", when: "", want: true},
+		{name: "without braces", when: `eq .Steps.ping.Status "success"`, want: true},
+		{name: "with braces", when: `${{ eq .Steps.ping.Status "failed" }}`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := shared_models.Action{Params: []shared_models.Params{{Key: paramWhen, Value: tt.when}}}
+
+			got, err := evaluateCondition(action, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
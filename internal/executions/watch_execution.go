@@ -0,0 +1,97 @@
+package internal_executions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/executions"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pausedCheckInterval is how often a paused execution is checked if status_check_interval is 0
+const pausedCheckInterval = 10 * time.Second
+
+// errCanceledRemotely is the cancel cause of executions canceled on the platform
+var errCanceledRemotely = errors.New("execution canceled on the platform")
+
+// canceledRemotely reports whether the execution context was canceled by watchExecution
+func canceledRemotely(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errCanceledRemotely)
+}
+
+// executionCanceled reports whether the execution context was canceled rather than
+// timed out, by the platform, a shutdown or a newer execution of its concurrency group
+func executionCanceled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// watchExecution polls the execution and its steps until ctx is done, pushed
+// events of the execution check it right away. Once the
+// execution or one of its steps got canceled on the platform it cancels ctx,
+// which interrupts the running plugin calls. A paused execution closes the gate
+// for new steps until the platform reports it as running again, it is also checked
+// without a status_check_interval.
+func watchExecution(ctx context.Context, cancel context.CancelCauseFunc, gate *pauseGate, cfg config.Config, execution shared_models.Executions, targetPlatform string) {
+	// pushed events of the execution trigger a check right away
+	events, unsubscribe := pushed.subscribe(execution.ID.String())
+	defer unsubscribe()
+
+	// without status checks a paused execution is still checked until it is resumed
+	interval := cfg.StatusCheckInterval
+	onlyPaused := interval <= 0
+	if onlyPaused {
+		interval = pausedCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if onlyPaused && !gate.isPaused() {
+				continue
+			}
+		case <-events:
+		}
+
+		checkedAt := time.Now()
+		current, err := executions.GetExecution(cfg, execution.ID.String(), targetPlatform)
+		if err != nil {
+			log.Warnf("Failed to check status of execution %s: %v", execution.ID, err)
+			continue
+		}
+		switch current.Status {
+		case "canceled":
+			log.Infof("Execution %s was canceled on %s", execution.ID, targetPlatform)
+			cancel(errCanceledRemotely)
+			return
+		case "paused":
+			if gate.pause() {
+				log.Infof("Execution %s was paused on %s", execution.ID, targetPlatform)
+			}
+		case "running":
+			if gate.resume(checkedAt) {
+				log.Infof("Execution %s was resumed on %s", execution.ID, targetPlatform)
+			}
+		}
+
+		steps, err := executions.GetSteps(cfg, execution.ID.String(), targetPlatform)
+		if err != nil {
+			log.Warnf("Failed to check steps of execution %s for cancellation: %v", execution.ID, err)
+			continue
+		}
+		for _, step := range steps {
+			if step.Status == "canceled" && step.CanceledBy != "" && step.CanceledBy != "Runner" {
+				log.Infof("Step %s of execution %s was canceled by %s", step.ID, execution.ID, step.CanceledBy)
+				cancel(errCanceledRemotely)
+				return
+			}
+		}
+	}
+}
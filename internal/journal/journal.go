@@ -0,0 +1,233 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Entry is a single line of the journal of an execution
+type Entry struct {
+	Time      time.Time                 `json:"time"`
+	Type      string                    `json:"type"`
+	Status    string                    `json:"status"`
+	Platform  string                    `json:"platform,omitempty"`
+	AlertID   string                    `json:"alert_id,omitempty"`
+	Execution *shared_models.Executions `json:"execution,omitempty"`
+	StepID    string                    `json:"step_id,omitempty"`
+	ActionID  string                    `json:"action_id,omitempty"`
+	Data      map[string]interface{}    `json:"data,omitempty"`
+}
+
+// Record is the state of an execution replayed from its journal
+type Record struct {
+	Platform  string
+	AlertID   string
+	Execution shared_models.Executions
+	Status    string
+	Steps     map[string]StepRecord
+}
+
+// StepRecord is the last known state of a step
+type StepRecord struct {
+	StepID   string
+	ActionID string
+	Status   string
+	Data     map[string]interface{}
+}
+
+var (
+	dir string
+	mu  sync.Mutex
+)
+
+// Init enables the journal in the journal folder of the workspace dir
+func Init(workspaceDir string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	journalDir := filepath.Join(workspaceDir, "journal")
+	if err := os.MkdirAll(journalDir, 0755); err != nil {
+		return fmt.Errorf("failed to create journal dir: %w", err)
+	}
+	dir = journalDir
+
+	return nil
+}
+
+func path(executionID string) string {
+	return filepath.Join(dir, executionID+".jsonl")
+}
+
+// write appends an entry to the journal of the execution and syncs it to disk
+func write(executionID string, entry Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if dir == "" {
+		return
+	}
+
+	entry.Time = time.Now()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Errorf("Failed to encode journal entry of execution %s: %v", executionID, err)
+		return
+	}
+
+	file, err := os.OpenFile(path(executionID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Errorf("Failed to open journal of execution %s: %v", executionID, err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		log.Errorf("Failed to write journal of execution %s: %v", executionID, err)
+		return
+	}
+	if err := file.Sync(); err != nil {
+		log.Errorf("Failed to sync journal of execution %s: %v", executionID, err)
+	}
+}
+
+// Begin records that the runner started to process an execution
+func Begin(platform string, execution shared_models.Executions, alertID string) {
+	write(execution.ID.String(), Entry{
+		Type:      "execution",
+		Status:    "running",
+		Platform:  platform,
+		AlertID:   alertID,
+		Execution: &execution,
+	})
+}
+
+// Transition records a status change of the execution, e.g. paused
+func Transition(executionID string, status string) {
+	write(executionID, Entry{
+		Type:   "execution",
+		Status: status,
+	})
+}
+
+// Step records the status of a step and its output once it is finished
+func Step(executionID string, step shared_models.ExecutionSteps, status string, data map[string]interface{}) {
+	write(executionID, Entry{
+		Type:     "step",
+		Status:   status,
+		StepID:   step.ID.String(),
+		ActionID: step.Action.ID.String(),
+		Data:     data,
+	})
+}
+
+// Finish removes the journal of an execution which has ended
+func Finish(executionID string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if dir == "" {
+		return
+	}
+
+	if err := os.Remove(path(executionID)); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Failed to remove journal of execution %s: %v", executionID, err)
+	}
+}
+
+// Load replays the journal of an execution
+func Load(executionID string) (Record, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return load(path(executionID))
+}
+
+// Interrupted returns the records of all executions whose journal was not finished
+func Interrupted() ([]Record, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if dir == "" {
+		return nil, nil
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal dir: %w", err)
+	}
+
+	var records []Record
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".jsonl") {
+			continue
+		}
+
+		record, err := load(filepath.Join(dir, file.Name()))
+		if err != nil {
+			log.Warnf("Skip unreadable journal %s: %v", file.Name(), err)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func load(file string) (Record, error) {
+	record := Record{
+		Steps: make(map[string]StepRecord),
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return record, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// a crash while writing leaves an incomplete last line behind
+			log.Warnf("Skip incomplete journal entry in %s", file)
+			continue
+		}
+
+		switch entry.Type {
+		case "execution":
+			if entry.Execution != nil {
+				record.Execution = *entry.Execution
+				record.Platform = entry.Platform
+				record.AlertID = entry.AlertID
+			}
+			record.Status = entry.Status
+		case "step":
+			record.Steps[entry.StepID] = StepRecord{
+				StepID:   entry.StepID,
+				ActionID: entry.ActionID,
+				Status:   entry.Status,
+				Data:     entry.Data,
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return record, err
+	}
+
+	if record.Platform == "" {
+		return record, fmt.Errorf("journal has no execution entry")
+	}
+
+	return record, nil
+}
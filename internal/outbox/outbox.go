@@ -0,0 +1,306 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/api"
+	"github.com/v1Flows/runner/pkg/platform"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Entry is an update of an execution or one of its steps which is waiting to be sent to its platform
+type Entry struct {
+	Seq         uint64                        `json:"seq"`
+	Time        time.Time                     `json:"time"`
+	Platform    string                        `json:"platform"`
+	ExecutionID string                        `json:"execution_id"`
+	Execution   *shared_models.Executions     `json:"execution,omitempty"`
+	Step        *shared_models.ExecutionSteps `json:"step,omitempty"`
+}
+
+var (
+	dir   string
+	mu    sync.Mutex
+	seq   uint64
+	queue []Entry
+	// pending counts the queued entries per execution
+	pending = make(map[string]int)
+
+	// replayMu keeps replays from sending the same entries at the same time
+	replayMu sync.Mutex
+)
+
+// Init enables the outbox in the outbox folder of the workspace dir and loads
+// the updates which were still queued when the runner stopped
+func Init(workspaceDir string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	outboxDir := filepath.Join(workspaceDir, "outbox")
+	if err := os.MkdirAll(outboxDir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox dir: %w", err)
+	}
+
+	files, err := os.ReadDir(outboxDir)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox dir: %w", err)
+	}
+
+	queue = nil
+	pending = make(map[string]int)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		// an entry whose write was interrupted was never acknowledged
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			os.Remove(filepath.Join(outboxDir, file.Name()))
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(outboxDir, file.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read outbox entry %s: %w", file.Name(), err)
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(content, &entry); err != nil {
+			log.Warnf("Discarding unreadable outbox entry %s: %v", file.Name(), err)
+			os.Remove(filepath.Join(outboxDir, file.Name()))
+			continue
+		}
+
+		queue = append(queue, entry)
+		pending[entry.ExecutionID]++
+		if entry.Seq > seq {
+			seq = entry.Seq
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		return queue[i].Seq < queue[j].Seq
+	})
+	dir = outboxDir
+
+	if len(queue) > 0 {
+		log.Infof("Outbox holds %d updates which were not sent yet", len(queue))
+	}
+
+	return nil
+}
+
+// Len returns the number of queued updates
+func Len() int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return len(queue)
+}
+
+// Execution returns the last queued update of the execution. Reads from the
+// platform are stale while it is queued, the platform holds it once it was replayed.
+func Execution(executionID string) (shared_models.Executions, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := len(queue) - 1; i >= 0; i-- {
+		if queue[i].ExecutionID == executionID && queue[i].Execution != nil {
+			return *queue[i].Execution, true
+		}
+	}
+	return shared_models.Executions{}, false
+}
+
+// Step returns the last queued update of the step, like Execution
+func Step(executionID string, stepID string) (shared_models.ExecutionSteps, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := len(queue) - 1; i >= 0; i-- {
+		if queue[i].ExecutionID == executionID && queue[i].Step != nil && queue[i].Step.ID.String() == stepID {
+			return *queue[i].Step, true
+		}
+	}
+	return shared_models.ExecutionSteps{}, false
+}
+
+func path(seq uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d.json", seq))
+}
+
+// UpdateExecution sends the execution to its platform. If the platform is
+// unreachable, or earlier updates of the execution are still queued, the update
+// is queued and nil is returned.
+func UpdateExecution(cfg config.Config, execution shared_models.Executions, targetPlatform string) error {
+	return deliver(cfg, Entry{
+		Platform:    targetPlatform,
+		ExecutionID: execution.ID.String(),
+		Execution:   &execution,
+	})
+}
+
+// UpdateStep sends the step to its platform, it is queued like an update of the execution
+func UpdateStep(cfg config.Config, executionID string, step shared_models.ExecutionSteps, targetPlatform string) error {
+	return deliver(cfg, Entry{
+		Platform:    targetPlatform,
+		ExecutionID: executionID,
+		Step:        &step,
+	})
+}
+
+func deliver(cfg config.Config, entry Entry) error {
+	mu.Lock()
+	enabled := dir != ""
+	queued := pending[entry.ExecutionID] > 0
+	mu.Unlock()
+
+	// updates of an execution are sent in order, so they wait for the ones which are queued already
+	if enabled && queued {
+		return enqueue(entry)
+	}
+
+	err := send(cfg, entry)
+	if err == nil || !enabled || !api.Unreachable(err) {
+		return err
+	}
+
+	log.Warnf("%s is unreachable, queueing update of execution %s: %v", entry.Platform, entry.ExecutionID, err)
+	return enqueue(entry)
+}
+
+// send updates the execution or the step of the entry at its platform
+func send(cfg config.Config, entry Entry) error {
+	driver, err := platform.Get(cfg, entry.Platform)
+	if err != nil {
+		return err
+	}
+
+	if entry.Step != nil {
+		return driver.UpdateStep(cfg, entry.ExecutionID, *entry.Step)
+	}
+	return driver.UpdateExecution(cfg, *entry.Execution)
+}
+
+// enqueue writes the entry to the outbox and syncs it to disk
+func enqueue(entry Entry) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	seq++
+	entry.Seq = seq
+	entry.Time = time.Now()
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox entry of execution %s: %w", entry.ExecutionID, err)
+	}
+
+	// the entry is written to a temporary file first so a crash never leaves half an entry behind
+	tmp := path(entry.Seq) + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox entry of execution %s: %w", entry.ExecutionID, err)
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write outbox entry of execution %s: %w", entry.ExecutionID, err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync outbox entry of execution %s: %w", entry.ExecutionID, err)
+	}
+	file.Close()
+	if err := os.Rename(tmp, path(entry.Seq)); err != nil {
+		return fmt.Errorf("failed to store outbox entry of execution %s: %w", entry.ExecutionID, err)
+	}
+
+	queue = append(queue, entry)
+	pending[entry.ExecutionID]++
+
+	return nil
+}
+
+// remove deletes a sent entry from the outbox
+func remove(entry Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := os.Remove(path(entry.Seq)); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Failed to remove outbox entry %d: %v", entry.Seq, err)
+	}
+
+	for i := range queue {
+		if queue[i].Seq == entry.Seq {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	pending[entry.ExecutionID]--
+	if pending[entry.ExecutionID] <= 0 {
+		delete(pending, entry.ExecutionID)
+	}
+}
+
+// Start replays the queued updates every replay interval
+func Start(cfg config.Config) {
+	ticker := time.NewTicker(cfg.Outbox.ReplayInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		Replay(cfg)
+	}
+}
+
+// Replay sends the queued updates in the order they were queued. Updates are
+// full states of an execution or step, so an update which is sent twice, e.g.
+// because the runner stopped before it was removed, has no further effect. The
+// updates of a platform which is still unreachable stay queued.
+func Replay(cfg config.Config) {
+	replayMu.Lock()
+	defer replayMu.Unlock()
+
+	mu.Lock()
+	entries := make([]Entry, len(queue))
+	copy(entries, queue)
+	mu.Unlock()
+
+	if len(entries) == 0 {
+		return
+	}
+
+	sent := 0
+	unreachable := make(map[string]bool)
+	for _, entry := range entries {
+		if unreachable[entry.Platform] {
+			continue
+		}
+
+		err := send(cfg, entry)
+		if err != nil && api.Unreachable(err) {
+			log.Warnf("%s is still unreachable, %d updates stay queued: %v", entry.Platform, Len(), err)
+			unreachable[entry.Platform] = true
+			continue
+		}
+		if err != nil {
+			// the platform rejected the update, sending it again would not change that
+			log.Errorf("Discarding queued update of execution %s: %v", entry.ExecutionID, err)
+		} else {
+			sent++
+		}
+
+		remove(entry)
+	}
+
+	if sent > 0 {
+		log.Infof("Sent %d queued updates, %d remaining", sent, Len())
+	}
+}
@@ -1,45 +1,67 @@
 package runner
 
 import (
-	"bytes"
-	"encoding/json"
-	"io"
-	"net/http"
+	"sync"
 
-	"github.com/v1Flows/alertFlow/services/backend/pkg/models"
 	"github.com/v1Flows/runner/config"
 	"github.com/v1Flows/runner/pkg/platform"
 
 	log "github.com/sirupsen/logrus"
 )
 
+var (
+	busyMu    sync.Mutex
+	busyCount = make(map[string]int)
+)
+
+// Busy tracks the running executions per platform. The runner is reported busy
+// when the first execution starts and idle once the last one has ended.
 func Busy(targetPlatform string, cfg config.Config, busy bool) {
-	payload := models.Runners{
-		ExecutingJob: busy,
+	busyMu.Lock()
+	if busy {
+		busyCount[targetPlatform]++
+		if busyCount[targetPlatform] > 1 {
+			busyMu.Unlock()
+			return
+		}
+	} else {
+		if busyCount[targetPlatform] == 0 {
+			busyMu.Unlock()
+			return
+		}
+		busyCount[targetPlatform]--
+		if busyCount[targetPlatform] > 0 {
+			busyMu.Unlock()
+			return
+		}
 	}
+	busyMu.Unlock()
 
-	url, apiKey, runnerID := platform.GetPlatformConfig(targetPlatform, cfg)
+	sendBusy(targetPlatform, cfg, busy)
+}
 
-	payloadBuf := new(bytes.Buffer)
-	json.NewEncoder(payloadBuf).Encode(payload)
-	req, err := http.NewRequest("PUT", url+"/api/v1/runners/"+runnerID+"/busy", payloadBuf)
-	if err != nil {
-		log.Fatal(err)
-	}
-	req.Header.Set("Authorization", apiKey)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatal(err)
+// ResetBusy reports the runner as not busy regardless of the executions which
+// did not end, e.g. when it shuts down
+func ResetBusy(targetPlatform string, cfg config.Config) {
+	busyMu.Lock()
+	wasBusy := busyCount[targetPlatform] > 0
+	busyCount[targetPlatform] = 0
+	busyMu.Unlock()
+
+	if wasBusy {
+		sendBusy(targetPlatform, cfg, false)
 	}
-	defer resp.Body.Close()
+}
 
-	body, err := io.ReadAll(resp.Body)
+func sendBusy(targetPlatform string, cfg config.Config, busy bool) {
+	driver, err := platform.Get(cfg, targetPlatform)
 	if err != nil {
-		log.Fatal(err)
+		log.Error(err)
+		return
 	}
 
-	if resp.StatusCode != 201 {
-		log.Error("Failed to set runner to busy at %s", targetPlatform)
-		log.Error("Response: ", string(body))
+	// a failed busy update must not stop the runner, e.g. while it shuts down
+	if err := driver.SetBusy(cfg, config.GetInstance().GetRunnerID(targetPlatform), busy); err != nil {
+		log.Errorf("Failed to set runner busy state at %s: %v", targetPlatform, err)
 	}
 }
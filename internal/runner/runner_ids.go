@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/v1Flows/runner/config"
+)
+
+// persistedRunner is the runner ID assigned by a platform connection. It is only
+// used as long as the connection points to the same URL.
+type persistedRunner struct {
+	URL      string `json:"url"`
+	RunnerID string `json:"runner_id"`
+}
+
+var runnerIDsMu sync.Mutex
+
+func runnerIDsPath(cfg config.Config) string {
+	return filepath.Join(cfg.WorkspaceDir, "runner_ids.json")
+}
+
+func loadRunnerIDs(cfg config.Config) (map[string]persistedRunner, error) {
+	runners := make(map[string]persistedRunner)
+
+	data, err := os.ReadFile(runnerIDsPath(cfg))
+	if errors.Is(err, os.ErrNotExist) {
+		return runners, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &runners); err != nil {
+		return nil, err
+	}
+	return runners, nil
+}
+
+// persistedRunnerID returns the runner ID the connection assigned on an earlier start
+func persistedRunnerID(cfg config.Config, connection config.PlatformConfig) (string, error) {
+	runnerIDsMu.Lock()
+	defer runnerIDsMu.Unlock()
+
+	runners, err := loadRunnerIDs(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	runner, ok := runners[connection.Name]
+	if !ok || runner.URL != connection.URL {
+		return "", nil
+	}
+	return runner.RunnerID, nil
+}
+
+// persistRunnerID stores the runner ID assigned by the connection for the next start
+func persistRunnerID(cfg config.Config, connection config.PlatformConfig, runnerID string) error {
+	runnerIDsMu.Lock()
+	defer runnerIDsMu.Unlock()
+
+	runners, err := loadRunnerIDs(cfg)
+	if err != nil {
+		return err
+	}
+	runners[connection.Name] = persistedRunner{
+		URL:      connection.URL,
+		RunnerID: runnerID,
+	}
+
+	data, err := json.MarshalIndent(runners, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.WorkspaceDir, 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash does not leave a broken file behind
+	tmp := runnerIDsPath(cfg) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, runnerIDsPath(cfg))
+}
@@ -1,13 +1,6 @@
 package runner
 
 import (
-	"bytes"
-	"encoding/json"
-	"io"
-	"net/http"
-	"time"
-
-	"github.com/google/uuid"
 	"github.com/v1Flows/runner/config"
 	"github.com/v1Flows/runner/pkg/platform"
 
@@ -19,76 +12,37 @@ func RegisterAtAPI(targetPlatform string, version string, plugins []shared_model
 	configManager := config.GetInstance()
 	cfg := configManager.GetConfig()
 
-	url, apiKey, runnerID := platform.GetPlatformConfig(targetPlatform, cfg)
-
-	var parsedRunnerID uuid.UUID
-	var err error
-	if runnerID != "" {
-		parsedRunnerID, err = uuid.Parse(runnerID)
-		if err != nil {
-			log.Fatalf("Invalid RunnerID: %v", err)
-		}
-	}
-
-	register := shared_models.Runners{
-		ID:            parsedRunnerID,
-		Registered:    true,
-		LastHeartbeat: time.Now(),
-		Version:       version,
-		Mode:          cfg.Mode,
-		Plugins:       plugins,
-		Actions:       actions,
-		Endpoints:     alertEndpoints,
-	}
-
-	payloadBuf := new(bytes.Buffer)
-	json.NewEncoder(payloadBuf).Encode(register)
-	req, err := http.NewRequest("PUT", url+"/api/v1/runners/register", payloadBuf)
+	driver, err := platform.Get(cfg, targetPlatform)
 	if err != nil {
 		log.Fatal(err)
 	}
-	req.Header.Set("Authorization", apiKey)
-
-	for i := 0; i < 3; i++ {
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			log.Errorf("Failed to send request: %v", err)
-			time.Sleep(5 * time.Second) // Add delay before retrying
-			continue
-		}
+	connection, _ := cfg.Platform(targetPlatform)
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close() // Close the body after reading
+	// without a configured runner ID the runner keeps the one assigned on an earlier start
+	runnerID := connection.RunnerID
+	if runnerID == "" {
+		runnerID, err = persistedRunnerID(cfg, connection)
 		if err != nil {
-			log.Errorf("Failed to read response body: %v", err)
-			time.Sleep(5 * time.Second) // Add delay before retrying
-			continue
+			log.Warnf("Failed to read the persisted runner ID of %s: %v", targetPlatform, err)
 		}
+	}
 
-		if resp.StatusCode == 201 {
-			var response struct {
-				RunnerID string `json:"runner_id"`
-			}
-			if err := json.Unmarshal(body, &response); err != nil {
-				log.Fatal(err)
-			}
-
-			runner_id := ""
-			if response.RunnerID == "" {
-				runner_id = configManager.GetRunnerID(targetPlatform)
-			} else {
-				runner_id = response.RunnerID
-			}
-
-			configManager.UpdateRunnerID(targetPlatform, runner_id)
+	runnerID, err = driver.Register(cfg, platform.Registration{
+		RunnerID:  runnerID,
+		Version:   version,
+		Mode:      cfg.Mode,
+		Plugins:   plugins,
+		Actions:   actions,
+		Endpoints: alertEndpoints,
+	})
+	if err != nil {
+		log.Fatalf("Failed to register at %s: %v", targetPlatform, err)
+	}
 
-			log.Info("Runner registered at "+targetPlatform+". ID: ", configManager.GetRunnerID(targetPlatform))
-			return
-		} else {
-			log.Errorf("Failed to register at "+targetPlatform+", attempt %d", i+1)
-			log.Errorf("Response: %s", string(body))
-			time.Sleep(5 * time.Second) // Add delay before retrying
-		}
+	configManager.UpdateRunnerID(targetPlatform, runnerID)
+	if err := persistRunnerID(cfg, connection, runnerID); err != nil {
+		log.Warnf("Failed to persist the runner ID of %s: %v", targetPlatform, err)
 	}
-	log.Fatal("Failed to register at " + targetPlatform + " after 3 attempts")
+
+	log.Info("Runner registered at "+targetPlatform+". ID: ", configManager.GetRunnerID(targetPlatform))
 }
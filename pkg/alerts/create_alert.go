@@ -0,0 +1,16 @@
+package alerts
+
+import (
+	"github.com/v1Flows/alertFlow/services/backend/pkg/models"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/platform"
+)
+
+// CreateAlert sends an alert to the platform, which starts an execution of its flow
+func CreateAlert(cfg config.Config, targetPlatform string, alert models.Alerts) error {
+	p, err := platform.GetAlerts(cfg, targetPlatform)
+	if err != nil {
+		return err
+	}
+	return p.CreateAlert(cfg, alert)
+}
@@ -0,0 +1,16 @@
+package alerts
+
+import (
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/platform"
+)
+
+// alertPlatform returns the connection the alert helpers call, the first connection whose driver serves alerts
+func alertPlatform(cfg config.Config) string {
+	for _, connection := range cfg.Platforms {
+		if platform.Alerts(cfg, connection.Name) {
+			return connection.Name
+		}
+	}
+	return ""
+}
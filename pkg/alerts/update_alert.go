@@ -1,12 +1,9 @@
 package alerts
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
-
 	"github.com/v1Flows/alertFlow/services/backend/pkg/models"
 	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/platform"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -14,32 +11,16 @@ import (
 func UpdateAlert(cfg config.Config, alert models.Alerts) {
 	log.Info("Updating Alert")
 
-	jsonPayload, err := json.Marshal(alert)
-	if err != nil {
-		log.Error(err)
-		return
-	}
-
-	// Add authorization
-	req, err := http.NewRequest("PUT", cfg.Alertflow.URL+"/api/v1/alerts/"+alert.ID.String(), bytes.NewReader(jsonPayload))
+	p, err := platform.GetAlerts(cfg, alertPlatform(cfg))
 	if err != nil {
-		log.Error(err)
+		log.Errorf("Failed to update alert: %v", err)
 		return
 	}
-	req.Header.Set("Authorization", cfg.Alertflow.APIKey)
 
-	client := &http.Client{}
-	res, err := client.Do(req)
-	if err != nil {
-		log.Error(err)
+	if err := p.UpdateAlert(cfg, alert); err != nil {
+		log.Errorf("Failed to update alert: %v", err)
 		return
 	}
-	defer res.Body.Close()
 
-	if res.StatusCode != 201 {
-		log.Error("Failed to update alert")
-		return
-	} else {
-		log.Info("Alert Updated")
-	}
+	log.Info("Alert Updated")
 }
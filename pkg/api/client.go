@@ -0,0 +1,271 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaults for clients created from a config which was not loaded
+	defaultTimeout      = 10 * time.Second
+	defaultRetryBackoff = time.Second
+	// maxRetryBackoff caps the exponential backoff between two attempts
+	maxRetryBackoff = 30 * time.Second
+)
+
+// transport is shared by the clients of all platforms so connections are kept alive and reused
+var transport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   20,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[string]*Client)
+)
+
+// Client calls the API of a platform connection. Idempotent calls are retried with
+// jittered exponential backoff on network errors, 429 and 5xx responses.
+type Client struct {
+	platform     string
+	url          string
+	apiKey       string
+	http         *http.Client
+	timeout      time.Duration
+	retries      int
+	retryBackoff time.Duration
+}
+
+// ForPlatform returns the client of the platform. Clients are created once per
+// platform connection and shared by all callers.
+func ForPlatform(cfg config.Config, targetPlatform string) *Client {
+	connection, _ := cfg.Platform(targetPlatform)
+	url, apiKey := connection.URL, connection.APIKey
+	key := targetPlatform + "|" + url + "|" + apiKey
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if client, ok := clients[key]; ok {
+		return client
+	}
+
+	client := &Client{
+		platform:     targetPlatform,
+		url:          strings.TrimSuffix(url, "/"),
+		apiKey:       apiKey,
+		http:         &http.Client{Transport: transport},
+		timeout:      cfg.API.Timeout,
+		retries:      cfg.API.Retries,
+		retryBackoff: cfg.API.RetryBackoff,
+	}
+	if client.timeout <= 0 {
+		client.timeout = defaultTimeout
+	}
+	if client.retryBackoff <= 0 {
+		client.retryBackoff = defaultRetryBackoff
+	}
+	clients[key] = client
+
+	return client
+}
+
+// Platform returns the name of the platform the client calls
+func (c *Client) Platform() string {
+	return c.platform
+}
+
+// CallOption changes a single call of the client
+type CallOption func(*call)
+
+type call struct {
+	timeout time.Duration
+	retries int
+	header  http.Header
+}
+
+// WithTimeout sets the timeout of every attempt of the call
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(c *call) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets how often the call is retried. Calls which are not idempotent are never retried.
+func WithRetries(retries int) CallOption {
+	return func(c *call) {
+		c.retries = retries
+	}
+}
+
+// WithHeader adds a header to the request
+func WithHeader(key string, value string) CallOption {
+	return func(c *call) {
+		c.header.Set(key, value)
+	}
+}
+
+// Get calls the path with GET and decodes the response into out
+func (c *Client) Get(ctx context.Context, path string, out interface{}, opts ...CallOption) error {
+	return c.Do(ctx, http.MethodGet, path, nil, out, opts...)
+}
+
+// Put sends in as json to the path and decodes the response into out
+func (c *Client) Put(ctx context.Context, path string, in interface{}, out interface{}, opts ...CallOption) error {
+	return c.Do(ctx, http.MethodPut, path, in, out, opts...)
+}
+
+// Post sends in as json to the path and decodes the response into out. It is never retried.
+func (c *Client) Post(ctx context.Context, path string, in interface{}, out interface{}, opts ...CallOption) error {
+	return c.Do(ctx, http.MethodPost, path, in, out, opts...)
+}
+
+// Do sends in as json body to the path and decodes the response into out. A nil
+// out discards the response, a *[]byte receives the raw body. Responses other
+// than 2xx are returned as *Error.
+func (c *Client) Do(ctx context.Context, method string, path string, in interface{}, out interface{}, opts ...CallOption) error {
+	options := call{
+		timeout: c.timeout,
+		retries: c.retries,
+		header:  make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if !idempotent(method) {
+		options.retries = 0
+	}
+
+	var payload []byte
+	if in != nil {
+		var err error
+		payload, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s %s: %w", method, path, err)
+		}
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var body []byte
+		body, err = c.attempt(ctx, method, path, payload, options)
+		if err == nil {
+			return decode(body, out, method, path)
+		}
+		if attempt >= options.retries || !Unreachable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		backoff := c.backoff(attempt)
+		log.Warnf("%s %s at %s failed, retry in %s: %v", method, path, c.platform, backoff.Round(time.Millisecond), err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// attempt sends the request once and returns the body of a 2xx response
+func (c *Client) attempt(ctx context.Context, method string, path string, payload []byte, options call) ([]byte, error) {
+	if c.url == "" {
+		return nil, fmt.Errorf("no url configured for platform %s", c.platform)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, options.timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", c.apiKey)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range options.header {
+		req.Header[key] = values
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &Error{
+			Platform:   c.platform,
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(body)),
+		}
+	}
+
+	return body, nil
+}
+
+// backoff doubles the delay with every attempt and adds up to 50% jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := c.retryBackoff << attempt
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff + rand.N(backoff/2+1)
+}
+
+func decode(body []byte, out interface{}, method string, path string) error {
+	switch target := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*target = body
+		return nil
+	default:
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+		}
+		return nil
+	}
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Error is returned for responses of the platform API other than 2xx
+type Error struct {
+	Platform   string
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s %s at %s API failed with status %d", e.Method, e.Path, e.Platform, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s at %s API failed with status %d: %s", e.Method, e.Path, e.Platform, e.StatusCode, e.Body)
+}
+
+// StatusCode returns the status code of an API error or 0 for any other error
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether the API answered with 404
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// Unreachable reports whether the platform could not be reached or was not able
+// to handle the call at the moment, so the same call may succeed when it is sent again
+func Unreachable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if status := StatusCode(err); status != 0 {
+		return status == http.StatusTooManyRequests || status >= 500
+	}
+
+	// network errors and timeouts of the attempt
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ServerEvent is a message of a Server-Sent Events stream
+type ServerEvent struct {
+	// Name is the event field, it is empty for unnamed messages
+	Name string
+	Data []byte
+}
+
+// EventStream reads the events of a Server-Sent Events stream
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	cancel  context.CancelFunc
+	idle    time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// Stream opens a Server-Sent Events stream at the path. Streams are neither
+// retried nor limited by the call timeout, they end once ctx is done or nothing,
+// not even a comment, was received for the idle timeout. A zero idle timeout never expires.
+func (c *Client) Stream(ctx context.Context, path string, idle time.Duration) (*EventStream, error) {
+	if c.url == "" {
+		return nil, fmt.Errorf("no url configured for platform %s", c.platform)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream := &EventStream{cancel: cancel, idle: idle}
+	if idle > 0 {
+		// a half-open connection is only noticed by the missing keep-alives
+		stream.timer = time.AfterFunc(idle, func() {
+			stream.expired.Store(true)
+			cancel()
+		})
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+	req.Header.Set("Authorization", c.apiKey)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer stream.Close()
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &Error{
+			Platform:   c.platform,
+			Method:     http.MethodGet,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(body)),
+		}
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		resp.Body.Close()
+		stream.Close()
+		return nil, fmt.Errorf("GET %s at %s API returned %q instead of an event stream", path, c.platform, contentType)
+	}
+
+	stream.body = resp.Body
+	stream.scanner = bufio.NewScanner(resp.Body)
+	stream.scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	return stream, nil
+}
+
+// Next blocks until the next event arrived. Comments, e.g. keep-alives, and the
+// id and retry fields are ignored. io.EOF is returned once the platform closed the stream.
+func (s *EventStream) Next() (ServerEvent, error) {
+	var event ServerEvent
+	var data bytes.Buffer
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if s.timer != nil {
+			s.timer.Reset(s.idle)
+		}
+
+		// an empty line dispatches the event
+		if line == "" {
+			if data.Len() > 0 {
+				event.Data = bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+				return event, nil
+			}
+			event = ServerEvent{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Name = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		}
+	}
+
+	if s.expired.Load() {
+		return ServerEvent{}, fmt.Errorf("nothing received for %s", s.idle)
+	}
+	if err := s.scanner.Err(); err != nil {
+		return ServerEvent{}, err
+	}
+	return ServerEvent{}, io.EOF
+}
+
+// Close closes the stream
+func (s *EventStream) Close() error {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.cancel()
+	if s.body == nil {
+		return nil
+	}
+	return s.body.Close()
+}
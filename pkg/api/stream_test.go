@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/v1Flows/runner/config"
+)
+
+// streamClient returns a client of a platform served by srv
+func streamClient(srv *httptest.Server) *Client {
+	cfg := config.Config{
+		Platforms: []config.PlatformConfig{{Name: "stream", Type: "alertflow", URL: srv.URL, APIKey: "key"}},
+	}
+	return ForPlatform(cfg, "stream")
+}
+
+func TestEventStreamNext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "key" || r.Header.Get("Accept") != "text/event-stream" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "id: 1\nretry: 1000\nevent: execution\ndata: {\"a\":1,\ndata: \"b\":2}\n\n")
+		fmt.Fprint(w, "data:unnamed\n\n")
+	}))
+	defer srv.Close()
+
+	stream, err := streamClient(srv).Stream(context.Background(), "/events", time.Minute)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	defer stream.Close()
+
+	event, err := stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if event.Name != "execution" || string(event.Data) != "{\"a\":1,\n\"b\":2}" {
+		t.Errorf("got event %q with data %q", event.Name, event.Data)
+	}
+
+	event, err = stream.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if event.Name != "" || string(event.Data) != "unnamed" {
+		t.Errorf("got event %q with data %q", event.Name, event.Data)
+	}
+
+	if _, err := stream.Next(); err != io.EOF {
+		t.Errorf("got %v at the end of the stream, want io.EOF", err)
+	}
+}
+
+func TestEventStreamIdleTimeout(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		// a half-open connection which never sends anything
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	stream, err := streamClient(srv).Stream(context.Background(), "/events", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	defer stream.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := stream.Next()
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err == nil || err == io.EOF {
+			t.Errorf("got %v from an idle stream, want an idle error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Next did not return on an idle stream")
+	}
+}
+
+func TestStreamRejectsResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, "{}")
+		}
+	}))
+	defer srv.Close()
+
+	client := streamClient(srv)
+
+	_, err := client.Stream(context.Background(), "/unavailable", time.Minute)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got %v, want a 503 error", err)
+	}
+	if !Unreachable(err) {
+		t.Errorf("a 503 of the stream is not reported as unreachable")
+	}
+
+	if _, err := client.Stream(context.Background(), "/json", time.Minute); err == nil {
+		t.Error("a response which is no event stream was accepted")
+	}
+}
@@ -1,17 +1,11 @@
 package executions
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
 	"time"
 
 	"github.com/v1Flows/runner/config"
 	"github.com/v1Flows/runner/internal/runner"
-	"github.com/v1Flows/runner/pkg/platform"
 	shared_models "github.com/v1Flows/shared-library/pkg/models"
-
-	log "github.com/sirupsen/logrus"
 )
 
 func EndCanceled(cfg config.Config, execution shared_models.Executions, targetPlatform string) {
@@ -38,25 +32,14 @@ func EndSuccess(cfg config.Config, execution shared_models.Executions, targetPla
 	End(cfg, execution, targetPlatform)
 }
 
-func End(cfg config.Config, execution shared_models.Executions, targetPlatform string) {
-	url, apiKey := platform.GetPlatformConfigPlain(targetPlatform, cfg)
+func EndSuccessWithWarnings(cfg config.Config, execution shared_models.Executions, targetPlatform string) {
+	execution.Status = "successWithWarnings"
+	execution.FinishedAt = time.Now()
+	End(cfg, execution, targetPlatform)
+}
 
+func End(cfg config.Config, execution shared_models.Executions, targetPlatform string) {
 	runner.Busy(targetPlatform, cfg, false)
 
-	payloadBuf := new(bytes.Buffer)
-	json.NewEncoder(payloadBuf).Encode(execution)
-
-	req, err := http.NewRequest("PUT", url+"/api/v1/executions/"+execution.ID.String(), payloadBuf)
-	if err != nil {
-		log.Error(err)
-	}
-	req.Header.Set("Authorization", apiKey)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Error(err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		log.Error("Failed to update execution at " + targetPlatform + " API")
-	}
+	UpdateExecution(cfg, execution, targetPlatform)
 }
@@ -0,0 +1,32 @@
+package executions
+
+import (
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/internal/outbox"
+	"github.com/v1Flows/runner/pkg/platform"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func GetExecution(cfg config.Config, executionID string, targetPlatform string) (shared_models.Executions, error) {
+	driver, err := platform.Get(cfg, targetPlatform)
+	if err != nil {
+		log.Error(err)
+		return shared_models.Executions{}, err
+	}
+
+	execution, err := driver.GetExecution(cfg, executionID)
+	// updates which are still queued are newer than what the platform returns
+	if queued, ok := outbox.Execution(executionID); ok {
+		return queued, nil
+	}
+	if err != nil {
+		log.Errorf("Failed to get execution data from %s API: %v", targetPlatform, err)
+		return shared_models.Executions{}, err
+	}
+
+	log.Debugf("Execution data received from %s API", targetPlatform)
+
+	return execution, nil
+}
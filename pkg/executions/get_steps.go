@@ -1,13 +1,8 @@
 package executions
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"time"
-
 	"github.com/v1Flows/runner/config"
-	"github.com/v1Flows/runner/pkg/models"
+	"github.com/v1Flows/runner/internal/outbox"
 	"github.com/v1Flows/runner/pkg/platform"
 	shared_models "github.com/v1Flows/shared-library/pkg/models"
 
@@ -15,42 +10,26 @@ import (
 )
 
 func GetSteps(cfg config.Config, executionID string, targetPlatform string) ([]shared_models.ExecutionSteps, error) {
-	client := http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			DisableKeepAlives: true,
-		},
-	}
-
-	url, apiKey := platform.GetPlatformConfigPlain(targetPlatform, cfg)
-
-	parsedUrl := url + "/api/v1/executions/" + executionID + "/steps"
-	req, err := http.NewRequest("GET", parsedUrl, nil)
-	if err != nil {
-		log.Errorf("Failed to create request: %v", err)
-		return []shared_models.ExecutionSteps{}, err
-	}
-	req.Header.Set("Authorization", apiKey)
-	resp, err := client.Do(req)
+	driver, err := platform.Get(cfg, targetPlatform)
 	if err != nil {
 		log.Error(err)
 		return []shared_models.ExecutionSteps{}, err
 	}
 
-	if resp.StatusCode != 200 {
-		log.Errorf("Failed to get step data from %s API: %s", targetPlatform, url)
-		err = fmt.Errorf("failed to get step data from %s API: %s", targetPlatform, url)
+	steps, err := driver.GetSteps(cfg, executionID)
+	if err != nil {
+		log.Errorf("Failed to get step data from %s API: %v", targetPlatform, err)
 		return []shared_models.ExecutionSteps{}, err
 	}
 
-	log.Debugf("Step data received from %s API: %s", targetPlatform, url)
+	log.Debugf("Step data received from %s API", targetPlatform)
 
-	var steps models.IncomingExecutionSteps
-	err = json.NewDecoder(resp.Body).Decode(&steps)
-	if err != nil {
-		log.Fatal(err)
-		return []shared_models.ExecutionSteps{}, err
+	// updates which are still queued are newer than what the platform returns
+	for i, step := range steps {
+		if queued, ok := outbox.Step(executionID, step.ID.String()); ok {
+			steps[i] = queued
+		}
 	}
 
-	return steps.StepsData, nil
+	return steps, nil
 }
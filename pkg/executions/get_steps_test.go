@@ -0,0 +1,115 @@
+package executions
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/internal/outbox"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// stepsPlatform serves the steps of one execution and refuses updates while unavailable is set
+type stepsPlatform struct {
+	mu          sync.Mutex
+	steps       map[string]shared_models.ExecutionSteps
+	order       []string
+	unavailable atomic.Bool
+}
+
+func (p *stepsPlatform) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		steps := []shared_models.ExecutionSteps{}
+		for _, id := range p.order {
+			steps = append(steps, p.steps[id])
+		}
+		if strings.HasSuffix(r.URL.Path, "/steps") {
+			json.NewEncoder(w).Encode(map[string]interface{}{"steps": steps})
+			return
+		}
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		json.NewEncoder(w).Encode(map[string]interface{}{"step": p.steps[id]})
+	case http.MethodPut:
+		if p.unavailable.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var step shared_models.ExecutionSteps
+		if err := json.NewDecoder(r.Body).Decode(&step); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		p.steps[step.ID.String()] = step
+	}
+}
+
+func TestReadsApplyQueuedUpdates(t *testing.T) {
+	if err := outbox.Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	executionID := uuid.New().String()
+	first := shared_models.ExecutionSteps{ID: uuid.New(), Status: "running"}
+	second := shared_models.ExecutionSteps{ID: uuid.New(), Status: "pending"}
+	stand := &stepsPlatform{
+		steps: map[string]shared_models.ExecutionSteps{first.ID.String(): first, second.ID.String(): second},
+		order: []string{first.ID.String(), second.ID.String()},
+	}
+	srv := httptest.NewServer(stand)
+	defer srv.Close()
+
+	cfg := config.Config{
+		Platforms: []config.PlatformConfig{{Name: "af", Type: "alertflow", URL: srv.URL, APIKey: "key"}},
+		API:       config.APIConfig{Timeout: time.Second, RetryBackoff: time.Millisecond},
+	}
+
+	// the platform is unavailable, so the success of the first step is queued
+	stand.unavailable.Store(true)
+	first.Status = "success"
+	if err := UpdateStep(cfg, executionID, first, "af"); err != nil {
+		t.Fatal(err)
+	}
+	if outbox.Len() != 1 {
+		t.Fatalf("outbox holds %d updates, want 1", outbox.Len())
+	}
+
+	steps, err := GetSteps(cfg, executionID, "af")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps[0].Status != "success" || steps[1].Status != "pending" {
+		t.Errorf("got statuses %s and %s, want success and pending", steps[0].Status, steps[1].Status)
+	}
+	step, err := GetStep(cfg, executionID, first.ID.String(), "af")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step.Status != "success" {
+		t.Errorf("got status %s for the queued step, want success", step.Status)
+	}
+
+	// once replayed the platform returns the update itself
+	stand.unavailable.Store(false)
+	outbox.Replay(cfg)
+	if outbox.Len() != 0 {
+		t.Fatalf("outbox holds %d updates after the replay", outbox.Len())
+	}
+	steps, err = GetSteps(cfg, executionID, "af")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps[0].Status != "success" {
+		t.Errorf("got status %s after the replay, want success", steps[0].Status)
+	}
+}
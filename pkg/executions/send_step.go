@@ -1,11 +1,6 @@
 package executions
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
-
 	"github.com/v1Flows/runner/config"
 	"github.com/v1Flows/runner/pkg/platform"
 	shared_models "github.com/v1Flows/shared-library/pkg/models"
@@ -14,30 +9,15 @@ import (
 )
 
 func SendStep(cfg config.Config, execution shared_models.Executions, step shared_models.ExecutionSteps, targetPlatform string) (shared_models.ExecutionSteps, error) {
-	payloadBuf := new(bytes.Buffer)
-	json.NewEncoder(payloadBuf).Encode(step)
-
-	url, apiKey := platform.GetPlatformConfigPlain(targetPlatform, cfg)
-
-	req, err := http.NewRequest("POST", url+"/api/v1/executions/"+execution.ID.String()+"/steps", payloadBuf)
+	driver, err := platform.Get(cfg, targetPlatform)
 	if err != nil {
 		log.Error(err)
-	}
-	req.Header.Set("Authorization", apiKey)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Error(err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 201 {
-		log.Error("Failed to send execution step at " + targetPlatform + " API")
-		return shared_models.ExecutionSteps{}, fmt.Errorf("failed to send execution step at " + targetPlatform + " api")
+		return shared_models.ExecutionSteps{}, err
 	}
 
-	var stepResponse shared_models.ExecutionSteps
-	err = json.NewDecoder(resp.Body).Decode(&stepResponse)
+	stepResponse, err := driver.SendStep(cfg, execution.ID.String(), step)
 	if err != nil {
-		log.Error(err)
+		log.Errorf("Failed to send execution step at %s API: %v", targetPlatform, err)
 		return shared_models.ExecutionSteps{}, err
 	}
 
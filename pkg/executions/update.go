@@ -1,37 +1,17 @@
 package executions
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
-
 	"github.com/v1Flows/runner/config"
-	"github.com/v1Flows/runner/pkg/platform"
+	"github.com/v1Flows/runner/internal/outbox"
 	shared_models "github.com/v1Flows/shared-library/pkg/models"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// UpdateExecution sends the execution to the platform, while it is unreachable the update is queued in the outbox
 func UpdateExecution(cfg config.Config, execution shared_models.Executions, targetPlatform string) error {
-	payloadBuf := new(bytes.Buffer)
-	json.NewEncoder(payloadBuf).Encode(execution)
-
-	url, apiKey := platform.GetPlatformConfigPlain(targetPlatform, cfg)
-
-	req, err := http.NewRequest("PUT", url+"/api/v1/executions/"+execution.ID.String(), payloadBuf)
-	if err != nil {
-		log.Error(err)
-		return err
-	}
-	req.Header.Set("Authorization", apiKey)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Error(err)
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		log.Error("Failed to update execution at " + targetPlatform + " API")
+	if err := outbox.UpdateExecution(cfg, execution, targetPlatform); err != nil {
+		log.Errorf("Failed to update execution at %s API: %v", targetPlatform, err)
 		return err
 	}
 
@@ -1,37 +1,17 @@
 package executions
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
-
 	"github.com/v1Flows/runner/config"
-	"github.com/v1Flows/runner/pkg/platform"
+	"github.com/v1Flows/runner/internal/outbox"
 	shared_models "github.com/v1Flows/shared-library/pkg/models"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// UpdateStep sends the step to the platform, while it is unreachable the update is queued in the outbox
 func UpdateStep(cfg config.Config, executionID string, step shared_models.ExecutionSteps, targetPlatform string) error {
-	payloadBuf := new(bytes.Buffer)
-	json.NewEncoder(payloadBuf).Encode(step)
-
-	url, apiKey := platform.GetPlatformConfigPlain(targetPlatform, cfg)
-
-	req, err := http.NewRequest("PUT", url+"/api/v1/executions/"+executionID+"/steps/"+step.ID.String(), payloadBuf)
-	if err != nil {
-		log.Error(err)
-		return err
-	}
-	req.Header.Set("Authorization", apiKey)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Error(err)
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		log.Error("Failed to send execution step at " + targetPlatform + " API")
+	if err := outbox.UpdateStep(cfg, executionID, step, targetPlatform); err != nil {
+		log.Errorf("Failed to update execution step at %s API: %v", targetPlatform, err)
 		return err
 	}
 
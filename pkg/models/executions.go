@@ -0,0 +1,9 @@
+package models
+
+import (
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+type IncomingExecution struct {
+	ExecutionData shared_models.Executions `json:"execution"`
+}
@@ -0,0 +1,118 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	af_models "github.com/v1Flows/alertFlow/services/backend/pkg/models"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/models"
+)
+
+// startTimeout is how long the platform may take to start the execution of an alert
+const startTimeout = time.Minute
+
+// startPollInterval is how often a started alert is checked for its execution
+var startPollInterval = time.Second
+
+// AlertPlatform is implemented by the drivers of platforms whose executions are
+// started by alerts, i.e. whose Alerts reports true
+type AlertPlatform interface {
+	Platform
+
+	GetAlert(cfg config.Config, alertID string) (af_models.Alerts, error)
+	// CreateAlert sends an alert, which starts an execution of its flow
+	CreateAlert(cfg config.Config, alert af_models.Alerts) error
+	UpdateAlert(cfg config.Config, alert af_models.Alerts) error
+	// GroupedAlerts returns the alerts of the flow sharing the group key
+	GroupedAlerts(cfg config.Config, flowID string, groupKey string) ([]af_models.Alerts, error)
+}
+
+// GetAlerts returns the platform of the connection with the name if it serves alerts
+func GetAlerts(cfg config.Config, name string) (AlertPlatform, error) {
+	p, err := Get(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	alerting, ok := p.(AlertPlatform)
+	if !ok || !p.Alerts() {
+		return nil, fmt.Errorf("platform %s does not serve alerts", name)
+	}
+	return alerting, nil
+}
+
+func (p *alertFlow) GetAlert(cfg config.Config, alertID string) (af_models.Alerts, error) {
+	var alert models.IncomingAlert
+	if err := p.client(cfg).Get(context.Background(), "/api/v1/alerts/"+alertID, &alert); err != nil {
+		return af_models.Alerts{}, err
+	}
+	return alert.AlertData, nil
+}
+
+func (p *alertFlow) CreateAlert(cfg config.Config, alert af_models.Alerts) error {
+	return p.client(cfg).Post(context.Background(), "/api/v1/alerts/", alert, nil)
+}
+
+func (p *alertFlow) UpdateAlert(cfg config.Config, alert af_models.Alerts) error {
+	return p.client(cfg).Put(context.Background(), "/api/v1/alerts/"+alert.ID.String(), alert, nil)
+}
+
+func (p *alertFlow) GroupedAlerts(cfg config.Config, flowID string, groupKey string) ([]af_models.Alerts, error) {
+	request := af_models.IncomingGroupedAlertsRequest{
+		FlowID:                flowID,
+		GroupAlertsIdentifier: groupKey,
+	}
+
+	// the grouped alerts are requested with GET and the filter as body
+	var alerts models.IncomingAlerts
+	if err := p.client(cfg).Do(context.Background(), http.MethodGet, "/api/v1/alerts/grouped", request, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts.Alerts, nil
+}
+
+// StartExecution starts the flow the way AlertFlow starts every execution, by an
+// alert. The alert gets its ID from the runner, so it can be read back until the
+// platform recorded the execution it started.
+func (p *alertFlow) StartExecution(ctx context.Context, cfg config.Config, flowID string, runnerID string, payload json.RawMessage) (PendingExecution, error) {
+	alert := af_models.Alerts{
+		ID:       uuid.New(),
+		Name:     "Execution of flow " + flowID + " started by runner " + runnerID,
+		Payload:  payload,
+		FlowID:   flowID,
+		RunnerID: runnerID,
+	}
+	if err := p.CreateAlert(cfg, alert); err != nil {
+		return PendingExecution{}, err
+	}
+	alertID := alert.ID.String()
+
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+	ticker := time.NewTicker(startPollInterval)
+	defer ticker.Stop()
+
+	for {
+		created, err := p.GetAlert(cfg, alertID)
+		if err == nil && created.ExecutionID != "" {
+			execution, err := p.GetExecution(cfg, created.ExecutionID)
+			if err != nil {
+				return PendingExecution{}, err
+			}
+			return PendingExecution{Execution: execution, AlertID: alertID}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+			return PendingExecution{}, fmt.Errorf("alert %s got no execution: %w", alertID, err)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	af_models "github.com/v1Flows/alertFlow/services/backend/pkg/models"
+	"github.com/v1Flows/runner/config"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// alertFlowStandIn stores created alerts and starts their execution after the first read
+type alertFlowStandIn struct {
+	mu          sync.Mutex
+	alerts      map[string]af_models.Alerts
+	reads       map[string]int
+	executionID uuid.UUID
+	// startAfter is the number of reads after which an alert has its execution, 0 never starts one
+	startAfter int
+}
+
+func (s *alertFlowStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/alerts/":
+		var alert af_models.Alerts
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.alerts[alert.ID.String()] = alert
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/v1/alerts/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/v1/alerts/")
+		alert, ok := s.alerts[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.reads[id]++
+		if s.startAfter > 0 && s.reads[id] >= s.startAfter {
+			alert.ExecutionID = s.executionID.String()
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"alert": alert})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/executions/"+s.executionID.String():
+		execution := shared_models.Executions{ID: s.executionID, FlowID: "flow", RunnerID: "r1"}
+		json.NewEncoder(w).Encode(map[string]interface{}{"execution": execution})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestStartExecution(t *testing.T) {
+	previous := startPollInterval
+	startPollInterval = 5 * time.Millisecond
+	defer func() { startPollInterval = previous }()
+
+	standIn := &alertFlowStandIn{
+		alerts:      make(map[string]af_models.Alerts),
+		reads:       make(map[string]int),
+		executionID: uuid.New(),
+		startAfter:  3,
+	}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	cfg := config.Config{
+		Platforms: []config.PlatformConfig{
+			{Name: "af", Type: "alertflow", URL: srv.URL, APIKey: "key"},
+			{Name: "ex", Type: "exflow", URL: srv.URL, APIKey: "key"},
+		},
+		API: config.APIConfig{Timeout: time.Second},
+	}
+
+	if _, err := GetStarter(cfg, "ex"); !errors.Is(err, ErrStartUnsupported) {
+		t.Errorf("got error %v for exflow, want ErrStartUnsupported", err)
+	}
+
+	starter, err := GetStarter(cfg, "af")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, err := starter.StartExecution(context.Background(), cfg, "flow", "r1", json.RawMessage(`{"host":"db1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Execution.ID != standIn.executionID {
+		t.Errorf("got execution %s, want %s", started.Execution.ID, standIn.executionID)
+	}
+
+	alert, ok := standIn.alerts[started.AlertID]
+	if !ok {
+		t.Fatalf("alert %s of the execution was not created", started.AlertID)
+	}
+	if alert.FlowID != "flow" || string(alert.Payload) != `{"host":"db1"}` {
+		t.Errorf("got alert of flow %s with payload %s", alert.FlowID, alert.Payload)
+	}
+
+	// an alert whose execution is never started ends with the context
+	standIn.startAfter = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := starter.StartExecution(ctx, cfg, "flow", "r1", json.RawMessage(`{}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want the deadline of the context", err)
+	}
+}
@@ -0,0 +1,172 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/v1Flows/runner/config"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+// Registration describes the runner when it registers at a platform
+type Registration struct {
+	RunnerID  string
+	Version   string
+	Mode      string
+	Plugins   []shared_models.Plugin
+	Actions   []shared_models.Action
+	Endpoints []shared_models.Endpoint
+}
+
+// PendingExecution is an execution waiting for the runner
+type PendingExecution struct {
+	Execution shared_models.Executions
+	// AlertID is the alert which started the execution on platforms with alerts
+	AlertID string
+}
+
+// Events pushed by a platform to the runner
+const (
+	// EventConnected is passed to the handler once the stream is open, it is not sent by the platform
+	EventConnected = "connected"
+	// EventExecution delivers a new execution for the runner
+	EventExecution = "execution"
+	// EventCancel tells that an execution or one of its steps got canceled
+	EventCancel = "cancel"
+	// EventInteraction tells that a step waiting for an interaction got an answer
+	EventInteraction = "interaction"
+	// EventStatus tells that an execution got paused or resumed
+	EventStatus = "status"
+)
+
+// Event is pushed by a platform. Besides new executions events only announce a
+// change, the runner reads the current state of the execution from the platform.
+type Event struct {
+	Type        string                    `json:"-"`
+	ExecutionID string                    `json:"execution_id"`
+	StepID      string                    `json:"step_id,omitempty"`
+	Execution   *shared_models.Executions `json:"execution,omitempty"`
+	AlertID     string                    `json:"alert_id,omitempty"`
+}
+
+// Platform is the driver of a connection to a v1Flows product
+type Platform interface {
+	// Name is the name of the connection, executions are routed by it
+	Name() string
+	// Type is the product the driver talks to, e.g. alertflow
+	Type() string
+	// Pipeline returns the steps which run before the actions of every flow, the
+	// pipeline of the connection or the default of the driver if it has none
+	Pipeline() []config.PipelineStepConfig
+	// Alerts reports whether executions are started by alerts. Only those
+	// platforms serve the alert endpoints and collect the alert of an execution.
+	Alerts() bool
+
+	// Register registers the runner and returns the runner ID assigned by the platform
+	Register(cfg config.Config, registration Registration) (string, error)
+	Heartbeat(cfg config.Config, runnerID string) error
+	SetBusy(cfg config.Config, runnerID string, busy bool) error
+	PendingExecutions(cfg config.Config, runnerID string) ([]PendingExecution, error)
+	// Subscribe receives the events the platform pushes to the runner and calls
+	// handle for each. It returns once the stream ended or ctx is done.
+	Subscribe(ctx context.Context, cfg config.Config, runnerID string, handle func(Event)) error
+
+	GetFlow(cfg config.Config, flowID string) ([]byte, error)
+	GetExecution(cfg config.Config, executionID string) (shared_models.Executions, error)
+	UpdateExecution(cfg config.Config, execution shared_models.Executions) error
+	GetSteps(cfg config.Config, executionID string) ([]shared_models.ExecutionSteps, error)
+	GetStep(cfg config.Config, executionID string, stepID string) (shared_models.ExecutionSteps, error)
+	SendStep(cfg config.Config, executionID string, step shared_models.ExecutionSteps) (shared_models.ExecutionSteps, error)
+	UpdateStep(cfg config.Config, executionID string, step shared_models.ExecutionSteps) error
+}
+
+// ExecutionStarter is implemented by the drivers of platforms on which the runner
+// can start executions itself, e.g. for sub flows
+type ExecutionStarter interface {
+	Platform
+
+	// StartExecution starts an execution of the flow and returns it once the platform
+	// created it. The payload is passed to the flow like the payload of an alert.
+	StartExecution(ctx context.Context, cfg config.Config, flowID string, runnerID string, payload json.RawMessage) (PendingExecution, error)
+}
+
+// ErrStartUnsupported is returned for platforms on which the runner cannot start executions
+var ErrStartUnsupported = errors.New("platform does not support starting executions")
+
+// GetStarter returns the platform of the connection with the name if the runner can start executions on it
+func GetStarter(cfg config.Config, name string) (ExecutionStarter, error) {
+	p, err := Get(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	starter, ok := p.(ExecutionStarter)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is of type %s", ErrStartUnsupported, name, p.Type())
+	}
+	return starter, nil
+}
+
+// Driver creates the platform of a connection
+type Driver func(connection config.PlatformConfig) Platform
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{
+		"alertflow": newAlertFlow,
+		"exflow":    newExFlow,
+	}
+)
+
+// Register adds the driver of a platform type. Registering a type twice panics.
+func Register(platformType string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if _, ok := drivers[platformType]; ok {
+		panic(fmt.Sprintf("platform type %s is already registered", platformType))
+	}
+	drivers[platformType] = driver
+}
+
+// Get returns the platform of the connection with the name. The connections are
+// taken from the config, so plugins can use it with the config they are passed.
+func Get(cfg config.Config, name string) (Platform, error) {
+	connection, ok := cfg.Platform(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown platform %s", name)
+	}
+	return connect(connection)
+}
+
+// Connections returns the platforms of all configured connections
+func Connections(cfg config.Config) ([]Platform, error) {
+	var platforms []Platform
+	for _, connection := range cfg.Platforms {
+		p, err := connect(connection)
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, p)
+	}
+	return platforms, nil
+}
+
+// Alerts reports whether executions of the platform are started by alerts
+func Alerts(cfg config.Config, name string) bool {
+	p, err := Get(cfg, name)
+	return err == nil && p.Alerts()
+}
+
+func connect(connection config.PlatformConfig) (Platform, error) {
+	driversMu.RLock()
+	driver, ok := drivers[connection.Type]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("platform %s has the unknown type %s", connection.Name, connection.Type)
+	}
+	return driver(connection), nil
+}
@@ -0,0 +1,254 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/v1Flows/runner/config"
+	"github.com/v1Flows/runner/pkg/api"
+	"github.com/v1Flows/runner/pkg/models"
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// registerRetries is how often registering at a platform is retried before the runner gives up
+const registerRetries = 5
+
+// alertFlow executes flows for alerts sent to AlertFlow
+type alertFlow struct {
+	v1FlowsAPI
+}
+
+// alertFlowPipeline runs for AlertFlow connections without a pipeline
+var alertFlowPipeline = []config.PipelineStepConfig{
+	{
+		Plugin: config.PipelineCollectData,
+		Params: []config.PipelineParamConfig{
+			{Key: "AlertID", Value: "{{ .AlertID }}"},
+			{Key: "FlowID", Value: "{{ .FlowID }}"},
+			{Key: "LogData", Value: "false"},
+		},
+	},
+	{Plugin: "pattern_check"},
+	{Plugin: "actions_check"},
+}
+
+func newAlertFlow(connection config.PlatformConfig) Platform {
+	return &alertFlow{v1FlowsAPI{connection: connection, defaultPipeline: alertFlowPipeline}}
+}
+
+func (p *alertFlow) Type() string {
+	return "alertflow"
+}
+
+func (p *alertFlow) Alerts() bool {
+	return true
+}
+
+// exFlow executes flows which are started on ExFlow
+type exFlow struct {
+	v1FlowsAPI
+}
+
+// exFlowPipeline runs for ExFlow connections without a pipeline
+var exFlowPipeline = []config.PipelineStepConfig{
+	{
+		Plugin: config.PipelineCollectData,
+		Params: []config.PipelineParamConfig{
+			{Key: "FlowID", Value: "{{ .FlowID }}"},
+			{Key: "LogData", Value: "false"},
+		},
+	},
+	{Plugin: "actions_check"},
+}
+
+func newExFlow(connection config.PlatformConfig) Platform {
+	return &exFlow{v1FlowsAPI{connection: connection, defaultPipeline: exFlowPipeline}}
+}
+
+func (p *exFlow) Type() string {
+	return "exflow"
+}
+
+func (p *exFlow) Alerts() bool {
+	return false
+}
+
+// v1FlowsAPI implements the calls of the runner API which all v1Flows products share
+type v1FlowsAPI struct {
+	connection config.PlatformConfig
+	// defaultPipeline runs if the connection has no pipeline
+	defaultPipeline []config.PipelineStepConfig
+}
+
+func (a v1FlowsAPI) Name() string {
+	return a.connection.Name
+}
+
+func (a v1FlowsAPI) Pipeline() []config.PipelineStepConfig {
+	if len(a.connection.Pipeline) == 0 {
+		return a.defaultPipeline
+	}
+	return a.connection.Pipeline
+}
+
+func (a v1FlowsAPI) client(cfg config.Config) *api.Client {
+	return api.ForPlatform(cfg, a.connection.Name)
+}
+func (a v1FlowsAPI) Register(cfg config.Config, registration Registration) (string, error) {
+	var runnerID uuid.UUID
+	if registration.RunnerID != "" {
+		var err error
+		runnerID, err = uuid.Parse(registration.RunnerID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	register := shared_models.Runners{
+		ID:            runnerID,
+		Registered:    true,
+		LastHeartbeat: time.Now(),
+		Version:       registration.Version,
+		Mode:          registration.Mode,
+		Plugins:       registration.Plugins,
+		Actions:       registration.Actions,
+		Endpoints:     registration.Endpoints,
+	}
+
+	var response struct {
+		RunnerID string `json:"runner_id"`
+	}
+	// the platform may still be starting, registering is retried for longer than other calls
+	err := a.client(cfg).Put(context.Background(), "/api/v1/runners/register", register, &response, api.WithRetries(registerRetries))
+	if err != nil {
+		return "", err
+	}
+
+	if response.RunnerID == "" {
+		return registration.RunnerID, nil
+	}
+	return response.RunnerID, nil
+}
+
+func (a v1FlowsAPI) Heartbeat(cfg config.Config, runnerID string) error {
+	return a.client(cfg).Put(context.Background(), "/api/v1/runners/"+runnerID+"/heartbeat", nil, nil)
+}
+
+func (a v1FlowsAPI) SetBusy(cfg config.Config, runnerID string, busy bool) error {
+	payload := shared_models.Runners{
+		ExecutingJob: busy,
+	}
+	return a.client(cfg).Put(context.Background(), "/api/v1/runners/"+runnerID+"/busy", payload, nil)
+}
+
+func (a v1FlowsAPI) PendingExecutions(cfg config.Config, runnerID string) ([]PendingExecution, error) {
+	var body []byte
+	if err := a.client(cfg).Get(context.Background(), "/api/v1/runners/"+runnerID+"/executions/pending", &body); err != nil {
+		return nil, err
+	}
+
+	// the alert of an execution is only known on platforms with alerts, it is empty otherwise
+	var executions struct {
+		Executions []shared_models.Executions `json:"executions"`
+	}
+	if err := json.Unmarshal(body, &executions); err != nil {
+		return nil, err
+	}
+	var alerts struct {
+		Executions []struct {
+			AlertID string `json:"alert_id"`
+		} `json:"executions"`
+	}
+	if err := json.Unmarshal(body, &alerts); err != nil {
+		return nil, err
+	}
+
+	pending := make([]PendingExecution, 0, len(executions.Executions))
+	for i, execution := range executions.Executions {
+		pending = append(pending, PendingExecution{
+			Execution: execution,
+			AlertID:   alerts.Executions[i].AlertID,
+		})
+	}
+	return pending, nil
+}
+
+func (a v1FlowsAPI) Subscribe(ctx context.Context, cfg config.Config, runnerID string, handle func(Event)) error {
+	stream, err := a.client(cfg).Stream(ctx, "/api/v1/runners/"+runnerID+"/events", cfg.Push.IdleTimeout)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	handle(Event{Type: EventConnected})
+
+	for {
+		message, err := stream.Next()
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal(message.Data, &event); err != nil {
+			log.Warnf("Ignoring invalid %s event from %s: %v", message.Name, a.connection.Name, err)
+			continue
+		}
+		event.Type = message.Name
+		if event.ExecutionID == "" && event.Execution != nil {
+			event.ExecutionID = event.Execution.ID.String()
+		}
+		handle(event)
+	}
+}
+
+func (a v1FlowsAPI) GetFlow(cfg config.Config, flowID string) ([]byte, error) {
+	var body []byte
+	if err := a.client(cfg).Get(context.Background(), "/api/v1/flows/"+flowID, &body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (a v1FlowsAPI) GetExecution(cfg config.Config, executionID string) (shared_models.Executions, error) {
+	var execution models.IncomingExecution
+	if err := a.client(cfg).Get(context.Background(), "/api/v1/executions/"+executionID, &execution); err != nil {
+		return shared_models.Executions{}, err
+	}
+	return execution.ExecutionData, nil
+}
+
+func (a v1FlowsAPI) UpdateExecution(cfg config.Config, execution shared_models.Executions) error {
+	return a.client(cfg).Put(context.Background(), "/api/v1/executions/"+execution.ID.String(), execution, nil)
+}
+
+func (a v1FlowsAPI) GetSteps(cfg config.Config, executionID string) ([]shared_models.ExecutionSteps, error) {
+	var steps models.IncomingExecutionSteps
+	if err := a.client(cfg).Get(context.Background(), "/api/v1/executions/"+executionID+"/steps", &steps); err != nil {
+		return nil, err
+	}
+	return steps.StepsData, nil
+}
+
+func (a v1FlowsAPI) GetStep(cfg config.Config, executionID string, stepID string) (shared_models.ExecutionSteps, error) {
+	var step models.IncomingExecutionStep
+	if err := a.client(cfg).Get(context.Background(), "/api/v1/executions/"+executionID+"/steps/"+stepID, &step); err != nil {
+		return shared_models.ExecutionSteps{}, err
+	}
+	return step.StepData, nil
+}
+
+func (a v1FlowsAPI) SendStep(cfg config.Config, executionID string, step shared_models.ExecutionSteps) (shared_models.ExecutionSteps, error) {
+	var created shared_models.ExecutionSteps
+	if err := a.client(cfg).Post(context.Background(), "/api/v1/executions/"+executionID+"/steps", step, &created); err != nil {
+		return shared_models.ExecutionSteps{}, err
+	}
+	return created, nil
+}
+
+func (a v1FlowsAPI) UpdateStep(cfg config.Config, executionID string, step shared_models.ExecutionSteps) error {
+	return a.client(cfg).Put(context.Background(), "/api/v1/executions/"+executionID+"/steps/"+step.ID.String(), step, nil)
+}
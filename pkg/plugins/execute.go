@@ -0,0 +1,35 @@
+package plugins
+
+import "context"
+
+// ContextPlugin is implemented by plugins running inside the runner which can
+// stop their work once the context is done
+type ContextPlugin interface {
+	ExecuteTaskContext(ctx context.Context, request ExecuteTaskRequest) (Response, error)
+}
+
+// ExecuteTaskWithContext calls ExecuteTask and returns as soon as ctx is done.
+// The rpc call itself can not be interrupted, its late response is discarded.
+func ExecuteTaskWithContext(ctx context.Context, plugin Plugin, request ExecuteTaskRequest) (Response, error) {
+	if p, ok := plugin.(ContextPlugin); ok {
+		return p.ExecuteTaskContext(ctx, request)
+	}
+
+	type result struct {
+		res Response
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		res, err := plugin.ExecuteTask(request)
+		done <- result{res: res, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	case r := <-done:
+		return r.res, r.err
+	}
+}
@@ -115,7 +115,7 @@ func Init(cfg config.Config) (loadedPlugin map[string]Plugin, plugins []shared_m
 
 		// Get plugin info
 		req := InfoRequest{
-			Config:    cfg,
+			Config:    cfg.WithoutPlatforms(),
 			Workspace: cfg.WorkspaceDir,
 		}
 		info, err := plugin.Info(req)
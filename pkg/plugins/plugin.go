@@ -17,6 +17,18 @@ type Plugin interface {
 	Info(request InfoRequest) (shared_models.Plugin, error)
 }
 
+// DryRunner is implemented by plugins which honor ExecuteTaskRequest.DryRun.
+// Plugins without it are not called for dry runs.
+type DryRunner interface {
+	SupportsDryRun() bool
+}
+
+// SupportsDryRun reports whether the plugin declared to make no side effects in a dry run
+func SupportsDryRun(p Plugin) bool {
+	dryRunner, ok := p.(DryRunner)
+	return ok && dryRunner.SupportsDryRun()
+}
+
 // PluginRPC is an implementation of net/rpc for Plugin
 type PluginRPC struct {
 	Client *rpc.Client
@@ -37,6 +49,8 @@ type ExecuteTaskRequest struct {
 	Alert     af_models.Alerts
 	Platform  string
 	Workspace string
+	// DryRun asks the plugin to validate the step without making any side effects
+	DryRun bool
 }
 
 type EndpointRequest struct {
@@ -71,6 +85,17 @@ func (p *PluginRPC) Info(request InfoRequest) (shared_models.Plugin, error) {
 	return resp, err
 }
 
+// SupportsDryRun asks the plugin process, plugins built before dry runs existed
+// do not serve the call and are treated as not supporting them. The argument is
+// unused, an int is sent since gob can not skip a nil interface for a missing method.
+func (p *PluginRPC) SupportsDryRun() bool {
+	var resp bool
+	if err := p.Client.Call("Plugin.SupportsDryRun", 0, &resp); err != nil {
+		return false
+	}
+	return resp
+}
+
 // PluginServer is the implementation of plugin.Plugin interface
 type PluginServer struct {
 	Impl Plugin
@@ -106,3 +131,8 @@ func (s *PluginRPCServer) Info(request InfoRequest, resp *shared_models.Plugin)
 	*resp = result
 	return err
 }
+
+func (s *PluginRPCServer) SupportsDryRun(args int, resp *bool) error {
+	*resp = SupportsDryRun(s.Impl)
+	return nil
+}
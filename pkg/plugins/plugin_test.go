@@ -0,0 +1,68 @@
+package plugins
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+
+	shared_models "github.com/v1Flows/shared-library/pkg/models"
+)
+
+type testPlugin struct{}
+
+func (testPlugin) ExecuteTask(request ExecuteTaskRequest) (Response, error) {
+	return Response{Success: true}, nil
+}
+
+func (testPlugin) EndpointRequest(request EndpointRequest) (Response, error) {
+	return Response{}, nil
+}
+
+func (testPlugin) Info(request InfoRequest) (shared_models.Plugin, error) {
+	return shared_models.Plugin{Name: "test"}, nil
+}
+
+type testDryRunPlugin struct{ testPlugin }
+
+func (testDryRunPlugin) SupportsDryRun() bool { return true }
+
+// legacyRPCServer serves the calls of plugins built before dry runs existed
+type legacyRPCServer struct{}
+
+func (legacyRPCServer) Info(request InfoRequest, resp *shared_models.Plugin) error {
+	return nil
+}
+
+// rpcClient connects a client to a plugin RPC server over an in-memory connection
+func rpcClient(t *testing.T, server interface{}) *PluginRPC {
+	t.Helper()
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("Plugin", server); err != nil {
+		t.Fatal(err)
+	}
+	serverConn, clientConn := net.Pipe()
+	go rpcServer.ServeConn(serverConn)
+
+	client := rpc.NewClient(clientConn)
+	t.Cleanup(func() { client.Close() })
+	return &PluginRPC{Client: client}
+}
+
+func TestSupportsDryRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		server interface{}
+		want   bool
+	}{
+		{name: "declared", server: &PluginRPCServer{Impl: testDryRunPlugin{}}, want: true},
+		{name: "not declared", server: &PluginRPCServer{Impl: testPlugin{}}, want: false},
+		{name: "plugin built before dry runs", server: legacyRPCServer{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SupportsDryRun(rpcClient(t, tt.server)); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}